@@ -0,0 +1,127 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cynx-io/janus-gateway/internal/constant"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+)
+
+const (
+	HeaderApiKey = "X-Api-Key"
+	schemeApiKey = "ApiKey"
+	prefix       = "janus_"
+)
+
+// FromRequest returns the API key sent as "Authorization: ApiKey <key>" or in
+// the X-Api-Key header, or an empty string.
+func FromRequest(r *http.Request) string {
+	if key := r.Header.Get(HeaderApiKey); key != "" {
+		return key
+	}
+
+	scheme, key, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, schemeApiKey) {
+		return strings.TrimSpace(key)
+	}
+	return ""
+}
+
+// Hash returns the hex-encoded SHA-256 of key, the form stored in config.
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Generate returns a new random API key.
+func Generate() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return prefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Lookup returns the configured API key matching key.
+func Lookup(key string) (config.ApiKeyConfig, bool) {
+	hash := []byte(Hash(key))
+	for _, k := range config.Config().ApiKeys {
+		if subtle.ConstantTimeCompare(hash, []byte(k.Hash)) == 1 {
+			return k, true
+		}
+	}
+	return config.ApiKeyConfig{}, false
+}
+
+// WithContext returns ctx carrying the API key the request authenticated with.
+func WithContext(ctx context.Context, key config.ApiKeyConfig) context.Context {
+	return context.WithValue(ctx, constant.ContextKeyApiKey, key)
+}
+
+// FromContext returns the API key the request authenticated with, if any.
+func FromContext(ctx context.Context) (config.ApiKeyConfig, bool) {
+	key, ok := ctx.Value(constant.ContextKeyApiKey).(config.ApiKeyConfig)
+	return key, ok
+}
+
+type window struct {
+	start time.Time
+	count int
+}
+
+var (
+	windowsMu sync.Mutex
+	windows   = map[string]*window{}
+)
+
+// Allow counts a request made with key against its per-minute rate limit. It
+// reports whether the request is allowed, how many requests remain in the
+// current minute and when the minute resets.
+func Allow(key config.ApiKeyConfig) (bool, int, time.Time) {
+	return AllowN(key, 1)
+}
+
+// AllowN counts n requests at once, such as the items of a batch, and allows
+// all of them or none.
+func AllowN(key config.ApiKeyConfig, n int) (bool, int, time.Time) {
+	if key.RateLimit == 0 {
+		return true, 0, time.Time{}
+	}
+
+	windowsMu.Lock()
+	defer windowsMu.Unlock()
+
+	now := time.Now()
+	w, ok := windows[key.Id]
+	if !ok || now.Sub(w.start) >= time.Minute {
+		w = &window{start: now.Truncate(time.Minute)}
+		windows[key.Id] = w
+	}
+	reset := w.start.Add(time.Minute)
+
+	if w.count+n > key.RateLimit {
+		return false, max(key.RateLimit-w.count, 0), reset
+	}
+	w.count += n
+	return true, key.RateLimit - w.count, reset
+}
+
+// SetRateLimitHeaders reports the rate limit of key in h, if it has one.
+func SetRateLimitHeaders(h http.Header, key config.ApiKeyConfig, remaining int, reset time.Time) {
+	if key.RateLimit == 0 {
+		return
+	}
+	h.Set("X-RateLimit-Limit", strconv.Itoa(key.RateLimit))
+	h.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	h.Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+}
@@ -0,0 +1,30 @@
+package apikey
+
+import (
+	"testing"
+
+	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+)
+
+func TestAllowN(t *testing.T) {
+	key := config.ApiKeyConfig{Id: "test-allow-n", RateLimit: 10}
+
+	tests := []struct {
+		n             int
+		wantAllowed   bool
+		wantRemaining int
+	}{
+		{n: 1, wantAllowed: true, wantRemaining: 9},
+		{n: 8, wantAllowed: true, wantRemaining: 1},
+		// All or none: a batch that does not fit takes nothing
+		{n: 2, wantAllowed: false, wantRemaining: 1},
+		{n: 1, wantAllowed: true, wantRemaining: 0},
+		{n: 1, wantAllowed: false, wantRemaining: 0},
+	}
+	for i, tt := range tests {
+		allowed, remaining, _ := AllowN(key, tt.n)
+		if allowed != tt.wantAllowed || remaining != tt.wantRemaining {
+			t.Errorf("call %d: AllowN(%d) = %v, %d, want %v, %d", i, tt.n, allowed, remaining, tt.wantAllowed, tt.wantRemaining)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package bearer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	gen "github.com/cynx-io/cynx-core/proto/gen"
+	"github.com/cynx-io/cynx-core/src/types/usertype"
+	pb "github.com/cynx-io/janus-gateway/api/proto/gen/hermes"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/auth0"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/upstream"
+	"github.com/cynx-io/janus-gateway/internal/helper"
+	"golang.org/x/oauth2"
+)
+
+// userTTL bounds how long a resolved Auth0 user is reused before the profile
+// is fetched again.
+const userTTL = 15 * time.Minute
+
+type cachedUser struct {
+	expiresAt time.Time
+	user      User
+}
+
+var (
+	usersMu sync.Mutex
+	users   = map[string]cachedUser{}
+)
+
+// resolveAuth0User maps the subject of a verified Auth0 access token to a
+// gateway user, the same way the login callback does: the profile comes from
+// the userinfo endpoint and is upserted into Hermes. Results are cached per
+// subject, so only the first request with a token pays for it.
+func resolveAuth0User(ctx context.Context, raw string, token *oidc.IDToken) (*User, error) {
+	now := time.Now()
+
+	usersMu.Lock()
+	cached, ok := users[token.Subject]
+	usersMu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return &cached.user, nil
+	}
+
+	info, err := auth0.Provider.UserInfo(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: raw}))
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := UpsertUser(ctx, token.Subject, info)
+	if err != nil {
+		return nil, err
+	}
+	user.Roles = helper.MergeRoles(auth0.Roles(token), user.Roles)
+
+	expiresAt := now.Add(userTTL)
+	if token.Expiry.Before(expiresAt) {
+		expiresAt = token.Expiry
+	}
+
+	usersMu.Lock()
+	defer usersMu.Unlock()
+	for sub, u := range users {
+		if now.After(u.expiresAt) {
+			delete(users, sub)
+		}
+	}
+	users[token.Subject] = cachedUser{expiresAt: expiresAt, user: *user}
+	return user, nil
+}
+
+// UpsertUser creates or updates the Hermes user of an Auth0 subject from the
+// email and name claims of its ID token or userinfo, and returns it with its
+// Hermes roles. Callers merge in the Auth0 roles.
+func UpsertUser(ctx context.Context, subject string, claims interface{ Claims(any) error }) (*User, error) {
+	var profile struct {
+		Email string `json:"email"`
+		Name  string `json:"name"`
+	}
+	if err := claims.Claims(&profile); err != nil {
+		return nil, errors.New("failed to get claims: " + err.Error())
+	}
+
+	isActive := true
+	user, _, err := upsert(ctx, &pb.UpsertUserRequest{
+		Base:     &gen.BaseRequest{},
+		Auth0Id:  subject,
+		Email:    profile.Email,
+		Name:     &profile.Name,
+		IsActive: &isActive,
+	})
+	return user, err
+}
+
+// ReloadUser returns the current Hermes user of an Auth0 subject, leaving its
+// name and active flag as they are. It fails for a deactivated user.
+func ReloadUser(ctx context.Context, subject string, email string) (*User, error) {
+	user, active, err := upsert(ctx, &pb.UpsertUserRequest{
+		Base:    &gen.BaseRequest{},
+		Auth0Id: subject,
+		Email:   email,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, ErrUserInactive
+	}
+	return user, nil
+}
+
+// upsert sends req to Hermes and returns the user and whether it is active.
+func upsert(ctx context.Context, req *pb.UpsertUserRequest) (*User, bool, error) {
+	conn, err := upstream.Dial("hermes")
+	if err != nil {
+		return nil, false, err
+	}
+
+	resp, err := pb.NewHermesUserServiceClient(conn).UpsertUser(ctx, req)
+	if err != nil {
+		return nil, false, err
+	}
+	if resp.GetUser() == nil {
+		return nil, false, errUserNotFound
+	}
+
+	return &User{
+		Name:     resp.User.Name,
+		Email:    resp.User.Email,
+		UserId:   resp.User.Id,
+		UserType: usertype.Normal,
+		Roles:    resp.User.Roles,
+	}, resp.User.IsActive, nil
+}
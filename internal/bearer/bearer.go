@@ -0,0 +1,110 @@
+package bearer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/cynx-io/cynx-core/src/types/usertype"
+	"github.com/cynx-io/janus-gateway/internal/constant"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/auth0"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Issuer is the iss claim of tokens issued by the gateway, which tells them
+// apart from Auth0 access tokens.
+const Issuer = "janus"
+
+var (
+	errUserNotFound = errors.New("user not found in Hermes response")
+	// ErrUserInactive is returned by ReloadUser for a deactivated user.
+	ErrUserInactive = errors.New("user is not active")
+)
+
+// Claims are the claims of a gateway-issued token.
+type Claims struct {
+	jwt.RegisteredClaims
+	Username string            `json:"username"`
+	UserId   int32             `json:"user_id"`
+	UserType usertype.UserType `json:"user_type"`
+	Roles    []string          `json:"roles,omitempty"`
+}
+
+// User is the caller identified by a bearer token.
+type User struct {
+	Name     string
+	Email    string
+	UserId   int32
+	UserType usertype.UserType
+	Roles    []string
+}
+
+// FromRequest returns the token sent as "Authorization: Bearer <token>", or
+// an empty string.
+func FromRequest(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+// Authenticate verifies raw, either a gateway-issued token or an Auth0 access
+// token, and returns its user.
+func Authenticate(ctx context.Context, raw string) (*User, error) {
+	unverified, _, err := jwt.NewParser().ParseUnverified(raw, &jwt.RegisteredClaims{})
+	if err != nil {
+		return nil, err
+	}
+
+	if iss, _ := unverified.Claims.GetIssuer(); iss == Issuer {
+		siteKey, _ := ctx.Value(constant.ContextKeySiteKey).(constant.SiteKey)
+		claims, err := ParseGateway(raw, siteKey)
+		if err != nil {
+			return nil, err
+		}
+		return &User{
+			Name:     claims.Username,
+			UserId:   claims.UserId,
+			UserType: claims.UserType,
+			Roles:    claims.Roles,
+		}, nil
+	}
+
+	token, err := VerifyAuth0(ctx, raw)
+	if err != nil {
+		return nil, err
+	}
+	return resolveAuth0User(ctx, raw, token)
+}
+
+// ParseGateway verifies an access token issued by the gateway for site.
+func ParseGateway(raw string, siteKey constant.SiteKey) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(raw, claims, verificationKey,
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg()}),
+		jwt.WithIssuer(Issuer),
+		jwt.WithAudience(string(siteKey)),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// VerifyAuth0 verifies an Auth0 access token against the provider JWKS. The
+// token must be issued for auth0.audience.
+func VerifyAuth0(ctx context.Context, raw string) (*oidc.IDToken, error) {
+	audience := config.Config().Auth0.Audience
+	if audience == "" {
+		return nil, errors.New("auth0.audience is not configured, Auth0 access tokens are not accepted")
+	}
+
+	// Access tokens are verified like ID tokens, with the API as the audience
+	verifier := auth0.Provider.Verifier(&oidc.Config{ClientID: audience})
+	return verifier.Verify(ctx, raw)
+}
@@ -0,0 +1,95 @@
+package bearer
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/cynx-io/janus-gateway/internal/constant"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// refreshIssuer is the iss claim of refresh tokens. They are signed with
+// jwt.secret and only accepted by Refresh, never as access tokens.
+const refreshIssuer = Issuer + "/refresh"
+
+const defaultRefreshExpiresInHours = 30 * 24
+
+// TokenResponse is the OAuth 2.0 token response of the exchange endpoint.
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// Issue issues an access token for user on site, and a refresh token for
+// grant if it names an Auth0 subject. Access tokens expire after
+// jwt.expiresInHours and are signed with the active key.
+func Issue(ctx context.Context, siteKey constant.SiteKey, user User, grant *Grant) (*TokenResponse, error) {
+	key, err := activeKey()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := config.Config().JWT
+	now := time.Now()
+
+	accessTTL := time.Duration(cfg.ExpiresInHours) * time.Hour
+	token := jwt.NewWithClaims(key.method, newClaims(Issuer, siteKey, user, now, accessTTL))
+	token.Header["kid"] = key.kid
+	access, err := token.SignedString(key.signer)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &TokenResponse{
+		AccessToken: access,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(accessTTL.Seconds()),
+	}
+	if grant == nil || grant.Subject == "" {
+		return resp, nil
+	}
+
+	// Refreshing never pushes back the end of the grant
+	next := *grant
+	next.Site = siteKey
+	next.UserID = user.UserId
+	if next.ExpiresAt.IsZero() {
+		refreshHours := cfg.RefreshExpiresInHours
+		if refreshHours == 0 {
+			refreshHours = defaultRefreshExpiresInHours
+		}
+		next.ExpiresAt = now.Add(time.Duration(refreshHours) * time.Hour)
+	}
+
+	claims := newClaims(refreshIssuer, siteKey, user, now, next.ExpiresAt.Sub(now))
+	resp.RefreshToken, err = jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(cfg.Secret))
+	if err != nil {
+		return nil, err
+	}
+	if err := saveGrant(ctx, claims.ID, next); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func newClaims(issuer string, siteKey constant.SiteKey, user User, now time.Time, ttl time.Duration) Claims {
+	return Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   strconv.Itoa(int(user.UserId)),
+			Audience:  jwt.ClaimStrings{string(siteKey)},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			ID:        uuid.NewString(),
+		},
+		Username: user.Name,
+		UserId:   user.UserId,
+		UserType: user.UserType,
+		Roles:    user.Roles,
+	}
+}
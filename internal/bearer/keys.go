@@ -0,0 +1,136 @@
+package bearer
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"sync"
+
+	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var errNoSigningKey = errors.New("no jwt.keys configured, gateway tokens cannot be issued")
+
+type signingKey struct {
+	signer crypto.Signer
+	method jwt.SigningMethod
+	kid    string
+}
+
+type keySet struct {
+	cfg  *config.AppConfig
+	keys []signingKey
+}
+
+var (
+	keysMu sync.Mutex
+	cached keySet
+)
+
+// signingKeys returns the parsed jwt.keys, parsing them again after a config
+// reload.
+func signingKeys() ([]signingKey, error) {
+	keysMu.Lock()
+	defer keysMu.Unlock()
+
+	cfg := config.Config()
+	if cached.cfg == cfg {
+		return cached.keys, nil
+	}
+
+	keys := make([]signingKey, 0, len(cfg.JWT.Keys))
+	for _, k := range cfg.JWT.Keys {
+		signer, err := k.Signer()
+		if err != nil {
+			return nil, errors.New("jwt key " + k.Kid + ": " + err.Error())
+		}
+
+		key := signingKey{signer: signer, kid: k.Kid, method: jwt.SigningMethodRS256}
+		if _, ok := signer.(*ecdsa.PrivateKey); ok {
+			key.method = jwt.SigningMethodES256
+		}
+		keys = append(keys, key)
+	}
+
+	cached = keySet{cfg: cfg, keys: keys}
+	return keys, nil
+}
+
+// activeKey returns the key new tokens are signed with.
+func activeKey() (signingKey, error) {
+	keys, err := signingKeys()
+	if err != nil {
+		return signingKey{}, err
+	}
+	if len(keys) == 0 {
+		return signingKey{}, errNoSigningKey
+	}
+	return keys[0], nil
+}
+
+// verificationKey is the jwt.Keyfunc for gateway access tokens, selecting the
+// public key by the kid header.
+func verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	keys, err := signingKeys()
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		if key.kid == kid {
+			if key.method.Alg() != token.Method.Alg() {
+				return nil, errors.New("token alg does not match key " + kid)
+			}
+			return key.signer.Public(), nil
+		}
+	}
+	return nil, errors.New("unknown kid " + kid)
+}
+
+// JWKS returns the public keys of jwt.keys as a JSON Web Key Set, so other
+// services can verify gateway access tokens.
+func JWKS() (map[string]interface{}, error) {
+	keys, err := signingKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	jwks := make([]map[string]interface{}, 0, len(keys))
+	for _, key := range keys {
+		jwk := map[string]interface{}{
+			"kid": key.kid,
+			"use": "sig",
+			"alg": key.method.Alg(),
+		}
+
+		switch pub := key.signer.Public().(type) {
+		case *rsa.PublicKey:
+			jwk["kty"] = "RSA"
+			jwk["n"] = encodeBigInt(pub.N, 0)
+			jwk["e"] = encodeBigInt(big.NewInt(int64(pub.E)), 0)
+		case *ecdsa.PublicKey:
+			jwk["kty"] = "EC"
+			jwk["crv"] = "P-256"
+			jwk["x"] = encodeBigInt(pub.X, 32)
+			jwk["y"] = encodeBigInt(pub.Y, 32)
+		}
+		jwks = append(jwks, jwk)
+	}
+
+	return map[string]interface{}{"keys": jwks}, nil
+}
+
+// encodeBigInt encodes n as unpadded base64url, left-padded with zeros to size
+// bytes.
+func encodeBigInt(n *big.Int, size int) string {
+	b := n.Bytes()
+	if len(b) < size {
+		b = append(make([]byte, size-len(b)), b...)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
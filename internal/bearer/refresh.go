@@ -0,0 +1,167 @@
+package bearer
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/cynx-io/janus-gateway/internal/constant"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/sessionstore"
+	"github.com/cynx-io/janus-gateway/internal/helper"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrInvalidGrant is returned by Refresh for a refresh token that is invalid,
+// expired, already used or revoked, or whose user is no longer active.
+var ErrInvalidGrant = errors.New("invalid refresh token")
+
+// Grant is what a refresh token was issued for. It is kept in the ephemeral
+// session store under the token id, so each refresh token works once and can
+// be revoked.
+type Grant struct {
+	Site   constant.SiteKey
+	UserID int32
+	// Subject is the Auth0 subject, whose Hermes user is reloaded on refresh
+	Subject string
+	Email   string
+	// Auth0Roles are the roles of the Auth0 login, merged with the reloaded
+	// Hermes roles
+	Auth0Roles []string
+	// Session is the public id of the session the tokens were exchanged for
+	Session string
+	// ExpiresAt is when the grant ends, however often it is refreshed
+	ExpiresAt time.Time
+}
+
+func grantKey(id string) string {
+	return "refresh:" + id
+}
+
+func grantIndexKey(userID int32) string {
+	return "user:" + strconv.Itoa(int(userID)) + ":refresh_tokens"
+}
+
+func saveGrant(ctx context.Context, id string, grant Grant) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(grant); err != nil {
+		return err
+	}
+
+	ttl := time.Until(grant.ExpiresAt)
+	store := sessionstore.Ephemeral()
+	if err := store.Save(ctx, grantKey(id), buf.Bytes(), ttl); err != nil {
+		return err
+	}
+	if sets, ok := store.(sessionstore.Sets); ok {
+		return sets.AddMember(ctx, grantIndexKey(grant.UserID), id, ttl)
+	}
+	return nil
+}
+
+func loadGrant(ctx context.Context, id string) (*Grant, error) {
+	data, _, err := sessionstore.Ephemeral().Load(ctx, grantKey(id))
+	if err != nil {
+		return nil, err
+	}
+	var grant Grant
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&grant); err != nil {
+		return nil, err
+	}
+	return &grant, nil
+}
+
+// Refresh uses up a refresh token issued for site and issues new tokens for
+// its user, reloaded from Hermes. The new refresh token ends with the grant
+// of the old one.
+func Refresh(ctx context.Context, siteKey constant.SiteKey, raw string) (*TokenResponse, error) {
+	secret := []byte(config.Config().JWT.Secret)
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(*jwt.Token) (interface{}, error) {
+		return secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(refreshIssuer),
+		jwt.WithAudience(string(siteKey)),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil || claims.ID == "" {
+		return nil, ErrInvalidGrant
+	}
+
+	grant, err := loadGrant(ctx, claims.ID)
+	if errors.Is(err, sessionstore.ErrNotFound) {
+		return nil, ErrInvalidGrant
+	}
+	if err != nil {
+		return nil, err
+	}
+	if grant.Site != siteKey || grant.UserID != claims.UserId {
+		return nil, ErrInvalidGrant
+	}
+
+	// Reload before using the token up, so a Hermes outage does not cost the
+	// client its refresh token
+	user, err := ReloadUser(ctx, grant.Subject, grant.Email)
+	if errors.Is(err, ErrUserInactive) {
+		return nil, ErrInvalidGrant
+	}
+	if err != nil {
+		return nil, err
+	}
+	user.Roles = helper.MergeRoles(grant.Auth0Roles, user.Roles)
+
+	// Only one of concurrent refreshes with the same token gets it
+	if _, err := sessionstore.Take(ctx, sessionstore.Ephemeral(), grantKey(claims.ID)); errors.Is(err, sessionstore.ErrNotFound) {
+		return nil, ErrInvalidGrant
+	} else if err != nil {
+		return nil, err
+	}
+	if sets, ok := sessionstore.Ephemeral().(sessionstore.Sets); ok {
+		if err := sets.RemoveMembers(ctx, grantIndexKey(grant.UserID), claims.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return Issue(ctx, siteKey, *user, grant)
+}
+
+// RevokeRefreshTokens revokes the refresh tokens of userID exchanged for the
+// session with the given public id, or all of them if session is empty.
+// Access tokens already issued last until they expire.
+func RevokeRefreshTokens(ctx context.Context, userID string, session string) error {
+	id, err := strconv.ParseInt(userID, 10, 32)
+	if err != nil {
+		return err
+	}
+	store := sessionstore.Ephemeral()
+	sets, ok := store.(sessionstore.Sets)
+	if !ok {
+		return nil
+	}
+
+	ids, err := sets.Members(ctx, grantIndexKey(int32(id)))
+	if err != nil {
+		return err
+	}
+	var revoked []string
+	for _, tokenID := range ids {
+		if session != "" {
+			grant, err := loadGrant(ctx, tokenID)
+			if err != nil && !errors.Is(err, sessionstore.ErrNotFound) {
+				return err
+			}
+			if grant != nil && grant.Session != session {
+				continue
+			}
+		}
+		if err := store.Delete(ctx, grantKey(tokenID)); err != nil {
+			return err
+		}
+		revoked = append(revoked, tokenID)
+	}
+	return sets.RemoveMembers(ctx, grantIndexKey(int32(id)), revoked...)
+}
@@ -0,0 +1,7 @@
+package constant
+
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProto    = "application/proto"
+	ContentTypeProtobuf = "application/x-protobuf"
+)
@@ -3,5 +3,8 @@ package constant
 import contextcore "github.com/cynx-io/cynx-core/src/context"
 
 const (
-	ContextKeySiteKey contextcore.Key = "site_key"
+	ContextKeySiteKey        contextcore.Key = "site_key"
+	ContextKeyDecodedRequest contextcore.Key = "decoded_request"
+	ContextKeyApiKey         contextcore.Key = "api_key"
+	ContextKeyRoles          contextcore.Key = "roles"
 )
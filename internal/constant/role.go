@@ -0,0 +1,8 @@
+package constant
+
+// RoleAdmin is the role required by routes registered on the admin router.
+const RoleAdmin = "admin"
+
+// MetadataUserRoles is the gRPC metadata key carrying the caller's roles to
+// upstreams, since core.BaseRequest has no field for them.
+const MetadataUserRoles = "x-user-roles"
@@ -0,0 +1,16 @@
+package constant
+
+// RouteAccess is the name given to the subrouter a route is registered on,
+// used to recover the access rules of a route when walking the router.
+type RouteAccess string
+
+const (
+	RouteAccessNone    RouteAccess = ""
+	RouteAccessPublic  RouteAccess = "public"
+	RouteAccessPrivate RouteAccess = "private"
+	RouteAccessAdmin   RouteAccess = "admin"
+)
+
+// RouteNameSiteless names routes that serve every site, such as the JWKS,
+// and so are not rejected when the request cannot be matched to a site.
+const RouteNameSiteless = "siteless"
@@ -1,9 +1,4 @@
 package constant
 
+// SiteKey identifies a site. It is the site's key under "sites" in config.
 type SiteKey string
-
-const (
-	SiteMakeadle SiteKey = "makeadle"
-	SiteRizzume  SiteKey = "rizzume"
-	SitePerintis SiteKey = "perintis"
-)
@@ -4,39 +4,94 @@ import (
 	"context"
 	"encoding/gob"
 	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/cynx-io/cynx-core/src/logger"
 	"github.com/cynx-io/janus-gateway/internal/constant"
 	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/sessionstore"
 	"github.com/gorilla/sessions"
 	"golang.org/x/oauth2"
 	"net/http"
+	"net/url"
+	"reflect"
+	"sync/atomic"
 	"time"
 )
 
 var (
 	Provider *oidc.Provider
-	Verifier map[constant.SiteKey]*oidc.IDTokenVerifier
-	Store    map[constant.SiteKey]*sessions.CookieStore
-	Oauth2   map[constant.SiteKey]*oauth2.Config
+	clients  atomic.Pointer[siteClients]
 )
 
+// siteClients holds the per-site Auth0 clients, rebuilt when sites change.
+type siteClients struct {
+	verifier map[constant.SiteKey]*oidc.IDTokenVerifier
+	store    map[constant.SiteKey]sessions.Store
+	oauth2   map[constant.SiteKey]*oauth2.Config
+}
+
+func Verifier(key constant.SiteKey) *oidc.IDTokenVerifier {
+	return clients.Load().verifier[key]
+}
+
+func Store(key constant.SiteKey) sessions.Store {
+	return clients.Load().store[key]
+}
+
+func Oauth2(key constant.SiteKey) *oauth2.Config {
+	return clients.Load().oauth2[key]
+}
+
+// Issuer is the issuer of the tokens of the Auth0 tenant, which ends in a
+// slash.
+func Issuer() string {
+	return "https://" + config.Config().Auth0.Domain + "/"
+}
+
+// LogoutURL returns the Auth0 URL that ends the Auth0 session of a site's
+// client and sends the browser to returnTo, if set.
+func LogoutURL(clientID string, returnTo string) string {
+	params := url.Values{}
+	params.Add("client_id", clientID)
+	if returnTo != "" {
+		params.Add("returnTo", returnTo)
+	}
+	return Issuer() + "v2/logout?" + params.Encode()
+}
+
 func Init() {
 	gob.Register(map[string]interface{}{})
 	gob.Register(time.Time{})
 
-	Verifier = make(map[constant.SiteKey]*oidc.IDTokenVerifier)
-	Store = make(map[constant.SiteKey]*sessions.CookieStore)
-	Oauth2 = make(map[constant.SiteKey]*oauth2.Config)
-
 	ctx := context.Background()
 	var err error
 
-	Provider, err = oidc.NewProvider(ctx, "https://"+config.Config.Auth0.Domain)
+	Provider, err = oidc.NewProvider(ctx, Issuer())
 	if err != nil {
-		panic("Failed to create OIDC provider on " + config.Config.Auth0.Domain + ": " + err.Error())
+		panic("Failed to create OIDC provider on " + config.Config().Auth0.Domain + ": " + err.Error())
+	}
+
+	clients.Store(newSiteClients(config.Config().Sites))
+	config.OnChange(reload)
+}
+
+func reload(prev, next *config.AppConfig) {
+	if prev.Auth0.Domain != next.Auth0.Domain {
+		logger.Error(context.Background(), "[AUTH0] Changing auth0.domain requires a restart, still using ", prev.Auth0.Domain)
+	}
+	if !reflect.DeepEqual(prev.Sites, next.Sites) {
+		clients.Store(newSiteClients(next.Sites))
+	}
+}
+
+func newSiteClients(sites config.SitesConfig) *siteClients {
+	c := &siteClients{
+		verifier: make(map[constant.SiteKey]*oidc.IDTokenVerifier),
+		store:    make(map[constant.SiteKey]sessions.Store),
+		oauth2:   make(map[constant.SiteKey]*oauth2.Config),
 	}
 
-	config.Config.Sites.Iterate(func(key constant.SiteKey, cfg config.SiteConfig) {
-		Oauth2[key] = &oauth2.Config{
+	sites.Iterate(func(key constant.SiteKey, cfg config.SiteConfig) {
+		c.oauth2[key] = &oauth2.Config{
 			ClientID:     cfg.Auth0.ClientId,
 			ClientSecret: cfg.Auth0.ClientSecret,
 			RedirectURL:  cfg.Auth0.CallbackUrl,
@@ -48,14 +103,10 @@ func Init() {
 			ClientID: cfg.Auth0.ClientId,
 		}
 
-		Verifier[key] = Provider.Verifier(oidcConfig)
+		c.verifier[key] = Provider.Verifier(oidcConfig)
 
 		sessionSecret := cfg.Auth0.SessionSecret
-		Store[key] = sessions.NewCookieStore(
-			[]byte(sessionSecret),      // hash key (must be 32 or 64 bytes)
-			[]byte(sessionSecret[:32]), // encryption key (must be 32 bytes for AES-256)
-		)
-		Store[key].Options = &sessions.Options{
+		options := sessions.Options{
 			Path:     "/",
 			MaxAge:   86400 * 7,
 			HttpOnly: true,
@@ -63,6 +114,19 @@ func Init() {
 			SameSite: http.SameSiteLaxMode,
 			Domain:   cfg.Domain,
 		}
+		if sessionstore.Enabled() {
+			// The cookie only carries the signed session id
+			c.store[key] = sessionstore.NewStore("session:"+string(key)+":", options, []byte(sessionSecret))
+			return
+		}
+
+		cookieStore := sessions.NewCookieStore(
+			[]byte(sessionSecret),      // hash key (must be 32 or 64 bytes)
+			[]byte(sessionSecret[:32]), // encryption key (must be 32 bytes for AES-256)
+		)
+		cookieStore.Options = &options
+		c.store[key] = cookieStore
 	})
 
+	return c
 }
@@ -0,0 +1,36 @@
+package auth0
+
+import (
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+)
+
+// Roles returns the roles listed in the auth0.roles_claim claim of a verified
+// ID or access token, added to tokens by an Auth0 Action. The claim may be a
+// list or a single string.
+func Roles(token *oidc.IDToken) []string {
+	claim := config.Config().Auth0.RolesClaim
+	if claim == "" {
+		return nil
+	}
+
+	var claims map[string]interface{}
+	if err := token.Claims(&claims); err != nil {
+		return nil
+	}
+
+	switch value := claims[claim].(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		roles := make([]string, 0, len(value))
+		for _, v := range value {
+			if role, ok := v.(string); ok {
+				roles = append(roles, role)
+			}
+		}
+		return roles
+	default:
+		return nil
+	}
+}
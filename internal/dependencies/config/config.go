@@ -1,11 +1,41 @@
 package config
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync/atomic"
+
 	"github.com/cynx-io/cynx-core/src/configuration"
 	"github.com/cynx-io/janus-gateway/internal/constant"
+	"github.com/joho/godotenv"
+	"github.com/spf13/viper"
 )
 
-var Config *AppConfig
+const path = "config.json"
+
+var current atomic.Pointer[AppConfig]
+
+// unresolved holds the secret references of the initial config that could
+// not be resolved, reported by Check.
+var unresolved error
+
+// Config returns the active configuration. It may be swapped by Reload, so
+// callers should not keep the returned pointer across requests.
+func Config() *AppConfig {
+	return current.Load()
+}
 
 type AppConfig struct {
 	Sites   SitesConfig `mapstructure:"sites"`
@@ -30,9 +60,20 @@ type AppConfig struct {
 	} `mapstructure:"plutus"`
 	Ananke struct {
 		Url string `mapstructure:"url"`
-	} `mapstructure:"Ananke"`
+	} `mapstructure:"ananke"`
 	Auth0 struct {
 		Domain string `mapstructure:"domain"`
+		// Audience is the API identifier Auth0 access tokens must be issued
+		// for to be accepted as bearer tokens
+		Audience string `mapstructure:"audience"`
+		// RolesClaim is the custom claim of ID and access tokens that lists
+		// the user's roles, e.g. "https://cynx.io/roles"
+		RolesClaim string `mapstructure:"roles_claim"`
+		// RefreshGrace is how long, in seconds, a refreshed token is handed
+		// to requests that still present the refresh token it replaced,
+		// 30 by default. Replicas only share it through a file or redis
+		// session store.
+		RefreshGrace int `mapstructure:"refresh_grace"`
 	} `mapstructure:"auth0"`
 	Cookie struct {
 		Name     string `mapstructure:"name"`
@@ -41,65 +82,377 @@ type AppConfig struct {
 		Secure   bool   `mapstructure:"secure"`
 		HttpOnly bool   `mapstructure:"http_only"`
 	} `mapstructure:"cookie"`
+	Session struct {
+		// Store keeps sessions in the encrypted cookie ("cookie", the default)
+		// or server-side in "memory", "file" or "redis", where the cookie
+		// only carries the session id
+		Store string `mapstructure:"store"`
+		// Ttl is how long, in seconds, a server-side session lives after it
+		// was last saved, or last used if Sliding is set. Defaults to 7 days.
+		Ttl     int  `mapstructure:"ttl"`
+		Sliding bool `mapstructure:"sliding"`
+		// GcInterval is how often, in seconds, expired sessions are removed
+		// from the memory and file stores. Defaults to 10 minutes.
+		GcInterval int    `mapstructure:"gc_interval"`
+		Dir        string `mapstructure:"dir"`
+		// RedisUrl is the redis:// or rediss:// URL of the shared cache,
+		// e.g. "redis://:password@cache:6379/0"
+		RedisUrl string `mapstructure:"redis_url" secret:"true"`
+	} `mapstructure:"session"`
 	JWT struct {
-		Secret         string `mapstructure:"secret"`
-		ExpiresInHours int    `mapstructure:"expiresInHours"`
+		// Secret signs refresh tokens, which only the gateway verifies
+		Secret                string `mapstructure:"secret" secret:"true"`
+		ExpiresInHours        int    `mapstructure:"expiresInHours"`
+		RefreshExpiresInHours int    `mapstructure:"refresh_expires_in_hours"`
+		// Keys sign access tokens. The first key signs new tokens; the others
+		// still verify, so a key can be rotated out once its tokens expire.
+		Keys []JWTKey `mapstructure:"keys"`
 	} `mapstructure:"jwt"`
 	App struct {
 		Address string `mapstructure:"address"`
 		Name    string `mapstructure:"name"`
-		Key     string `mapstructure:"key"`
+		Key     string `mapstructure:"key" secret:"true"`
 		Port    int    `mapstructure:"port"`
 		Debug   bool   `mapstructure:"debug"`
 	} `mapstructure:"app"`
 	CORS struct {
 		Enabled bool `mapstructure:"enabled"`
 	} `mapstructure:"cors"`
+	Rest        []RestMapping  `mapstructure:"rest"`
+	ApiKeys     []ApiKeyConfig `mapstructure:"api_keys"`
+	ApiKeysFile string         `mapstructure:"api_keys_file"`
+	Policies    []PolicyConfig `mapstructure:"policies"`
+	Batch       struct {
+		MaxItems       int `mapstructure:"max_items"`
+		MaxConcurrency int `mapstructure:"max_concurrency"`
+	} `mapstructure:"batch"`
+}
+
+// RestMapping exposes an RPC on a RESTful path, equivalent to a
+// google.api.http annotation on the method.
+type RestMapping struct {
+	Method string `mapstructure:"method"`
+	Path   string `mapstructure:"path"`
+	Rpc    string `mapstructure:"rpc"`
+	Body   string `mapstructure:"body"`
+}
+
+// JWTKey is a key pair for signing gateway access tokens, published on the
+// JWKS endpoint under Kid.
+type JWTKey struct {
+	Kid string `mapstructure:"kid"`
+	// PrivateKey is a PEM-encoded RSA or P-256 EC private key
+	PrivateKey string `mapstructure:"private_key" secret:"true"`
+}
+
+// Signer parses the private key.
+func (k JWTKey) Signer() (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(k.PrivateKey))
+	if block == nil {
+		return nil, errors.New("private_key is not PEM encoded")
+	}
+
+	var key any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		return key, nil
+	case *ecdsa.PrivateKey:
+		if key.Curve != elliptic.P256() {
+			return nil, errors.New("EC private_key must use the P-256 curve")
+		}
+		return key, nil
+	default:
+		return nil, errors.New("private_key must be an RSA or EC key")
+	}
 }
 
-type SitesConfig struct {
-	Makeadle SiteConfig `mapstructure:"makeadle"`
-	Rizzume  SiteConfig `mapstructure:"rizzume"`
-	Perintis SiteConfig `mapstructure:"perintis"`
+// ApiKeyConfig is an API key for server-to-server clients. Only the SHA-256
+// of the key is stored; requests made with it act on behalf of Site.
+type ApiKeyConfig struct {
+	// Id names the key in logs and in BaseRequest.username
+	Id string `mapstructure:"id" json:"id"`
+	// Hash is the hex-encoded SHA-256 of the key
+	Hash string           `mapstructure:"hash" json:"hash"`
+	Site constant.SiteKey `mapstructure:"site" json:"site"`
+	// Routes lists the methods the key may call, in the same form as
+	// SiteConfig.Services. A key without routes may call any method its site
+	// may call.
+	Routes []string `mapstructure:"routes" json:"routes"`
+	// RateLimit is the number of requests allowed per minute, 0 for no limit
+	RateLimit int `mapstructure:"rate_limit" json:"rate_limit"`
 }
 
+// Allows reports whether the key may call rpc, a full method name.
+func (k ApiKeyConfig) Allows(rpc string) bool {
+	return allows(k.Routes, rpc)
+}
+
+// PolicyConfig is an authorization rule evaluated before the upstream call
+// of the routes it applies to. Every matching policy must allow the call.
+type PolicyConfig struct {
+	// Name identifies the policy in logs
+	Name string `mapstructure:"name"`
+	// Routes lists the methods the policy applies to, in the same form as
+	// SiteConfig.Services
+	Routes []string `mapstructure:"routes"`
+	// Allow is a policy expression over user, site, origin, api_key, route
+	// and the decoded request, e.g. "request.user_id == user.id"
+	Allow string `mapstructure:"allow"`
+}
+
+// Applies reports whether the policy applies to rpc, a full method name.
+func (p PolicyConfig) Applies(rpc string) bool {
+	return len(p.Routes) > 0 && allows(p.Routes, rpc)
+}
+
+// SitesConfig maps each site key to its config. Sites are onboarded by adding
+// an entry under "sites"; the key is used as the site key everywhere.
+type SitesConfig map[constant.SiteKey]SiteConfig
+
 type SiteConfig struct {
 	Auth0 struct {
 		ClientId      string `mapstructure:"client_id"`
-		ClientSecret  string `mapstructure:"client_secret"`
+		ClientSecret  string `mapstructure:"client_secret" secret:"true"`
 		CallbackUrl   string `mapstructure:"callback_url"`
 		FrontendUrl   string `mapstructure:"frontend_url"`
-		SessionSecret string `mapstructure:"session_secret"`
+		SessionSecret string `mapstructure:"session_secret" secret:"true"`
+		// Connections and Organizations list the values /auth0/login
+		// accepts for its connection and organization options. Without
+		// entries the option is rejected.
+		Connections   []string `mapstructure:"connections"`
+		Organizations []string `mapstructure:"organizations"`
 	} `mapstructure:"auth0"`
 	ApiUrl string   `mapstructure:"api_url"`
 	Domain string   `mapstructure:"domain"`
 	Urls   []string `mapstructure:"urls"`
+	// Services lists the upstream methods the site may call, each a method
+	// ("plato.PlatoTopicService/GetTopicById"), a service
+	// ("plato.PlatoTopicService"), a proto package ("plato") or "*". A site
+	// without entries may call every service.
+	Services []string   `mapstructure:"services"`
+	CORS     CORSConfig `mapstructure:"cors"`
+	// RedirectPaths lists the path prefixes login and logout may redirect
+	// to, e.g. "/app". A site without entries may redirect to any path.
+	RedirectPaths []string `mapstructure:"redirect_paths"`
+	// SsoTargets lists the sites a logged in user of this site may be
+	// handed over to with a transfer code, without logging in again
+	SsoTargets []constant.SiteKey `mapstructure:"sso_targets"`
+}
+
+// CORSConfig is the CORS policy of a site's origins. Unset lists fall back to
+// the gateway defaults.
+type CORSConfig struct {
+	Methods        []string `mapstructure:"methods"`
+	Headers        []string `mapstructure:"headers"`
+	ExposedHeaders []string `mapstructure:"exposed_headers"`
+	// MaxAge is how long, in seconds, browsers may cache a preflight result
+	MaxAge int `mapstructure:"max_age"`
+	// PrivateNetwork allows Private Network Access preflights, for origins
+	// served from a public address calling a gateway on a private one
+	PrivateNetwork bool `mapstructure:"private_network"`
+}
+
+// AllowsOrigin reports whether origin matches one of the site's urls. A url
+// may have a wildcard subdomain, e.g. "https://*.perintis.app" matches
+// "https://course.perintis.app" but not "https://perintis.app".
+func (s SiteConfig) AllowsOrigin(origin string) bool {
+	for _, pattern := range s.Urls {
+		if matchOrigin(pattern, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchOrigin(pattern string, origin string) bool {
+	if pattern == origin {
+		return true
+	}
+
+	prefix, suffix, ok := strings.Cut(pattern, "*")
+	if !ok || len(origin) <= len(prefix)+len(suffix) {
+		return false
+	}
+	if !strings.HasPrefix(origin, prefix) || !strings.HasSuffix(origin, suffix) {
+		return false
+	}
+	// The wildcard stands for one or more DNS labels and nothing else
+	subdomain := origin[len(prefix) : len(origin)-len(suffix)]
+	for _, label := range strings.Split(subdomain, ".") {
+		if label == "" || strings.Trim(label, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-") != "" {
+			return false
+		}
+	}
+	return true
 }
 
+// Allows reports whether the site may call rpc, a full method name such as
+// "plato.PlatoTopicService/GetTopicById".
+func (s SiteConfig) Allows(rpc string) bool {
+	return allows(s.Services, rpc)
+}
+
+// allows reports whether rpc matches one of entries, each a method, a
+// service, a proto package or "*". No entries allow everything.
+func allows(entries []string, rpc string) bool {
+	if len(entries) == 0 {
+		return true
+	}
+
+	service, _, _ := strings.Cut(rpc, "/")
+	for _, entry := range entries {
+		if entry == "*" || entry == rpc || entry == service || strings.HasPrefix(service, entry+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// Iterate calls fn for every site in key order.
 func (s SitesConfig) Iterate(fn func(constant.SiteKey, SiteConfig)) {
-	fn(constant.SiteMakeadle, s.Makeadle)
-	fn(constant.SiteRizzume, s.Rizzume)
-	fn(constant.SitePerintis, s.Perintis)
+	keys := make([]constant.SiteKey, 0, len(s))
+	for key := range s {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	for _, key := range keys {
+		fn(key, s[key])
+	}
 }
 
+// AllowsSso reports whether users of site from may be handed over to site to.
+func (s SitesConfig) AllowsSso(from constant.SiteKey, to constant.SiteKey) bool {
+	_, known := s[to]
+	return known && from != to && slices.Contains(s.Get(from).SsoTargets, to)
+}
+
+// Get returns the config of siteKey, or a zero SiteConfig if it is unknown.
 func (s SitesConfig) Get(siteKey constant.SiteKey) SiteConfig {
-	switch siteKey {
-	case constant.SiteMakeadle:
-		return s.Makeadle
-	case constant.SiteRizzume:
-		return s.Rizzume
-	case constant.SitePerintis:
-		return s.Perintis
+	return s[siteKey]
+}
+
+// upstreams are the proto packages served by a gRPC upstream.
+var upstreams = []string{"hermes", "mercury", "plato", "philyra", "plutus", "ananke"}
+
+// Upstreams returns the proto packages served by a gRPC upstream.
+func Upstreams() []string {
+	return upstreams
+}
+
+// UpstreamUrl returns the gRPC address configured for a proto package,
+// e.g. "plato", or an empty string if the package has no upstream.
+func (c *AppConfig) UpstreamUrl(pkg string) string {
+	switch pkg {
+	case "hermes":
+		return c.Hermes.Url
+	case "mercury":
+		return c.Mercury.Url
+	case "plato":
+		return c.Plato.Url
+	case "philyra":
+		return c.Philyra.Url
+	case "plutus":
+		return c.Plutus.Url
+	case "ananke":
+		return c.Ananke.Url
 	default:
-		return SiteConfig{}
+		return ""
 	}
 }
 
 func Init() {
+	// Load .env file into environment variables
+	if err := godotenv.Load(); err != nil {
+		fmt.Println(".env file not found")
+	}
 
-	Config = &AppConfig{}
-	err := configuration.InitConfig("config.json", Config)
-	if err != nil {
+	viper.SetConfigFile(path)
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.AutomaticEnv()
+
+	if err := viper.ReadInConfig(); err != nil {
 		panic("failed to initialize config: " + err.Error())
 	}
+
+	cfg, err := decode()
+	if err != nil {
+		panic("failed to initialize config:\n" + err.Error())
+	}
+	// Commands such as routes run without the secrets; Check reports them
+	unresolved = resolveSecrets(cfg)
+	current.Store(cfg)
+}
+
+// decode builds an AppConfig from the config file already read by viper,
+// with environment overrides applied. Secret references are left to
+// resolveSecrets.
+func decode() (*AppConfig, error) {
+	bindEnvs()
+
+	cfg := &AppConfig{}
+	if err := viper.Unmarshal(cfg); err != nil {
+		return nil, err
+	}
+	if err := loadApiKeysFile(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadApiKeysFile appends the keys in api_keys_file, a JSON array of
+// ApiKeyConfig, so keys can be mounted separately from config.json.
+func loadApiKeysFile(cfg *AppConfig) error {
+	if cfg.ApiKeysFile == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(cfg.ApiKeysFile)
+	if err != nil {
+		return errors.New("api_keys_file: " + err.Error())
+	}
+
+	var keys []ApiKeyConfig
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return errors.New("api_keys_file: " + err.Error())
+	}
+	cfg.ApiKeys = append(cfg.ApiKeys, keys...)
+	return nil
+}
+
+// bindEnvs binds the environment variable of every key, e.g. APP_PORT for
+// app.port. BindEnvs would bind the sites map as a single key and hide the
+// keys of its entries, so each site in the config file is bound separately,
+// e.g. SITES_RIZZUME_AUTH0_CLIENT_SECRET.
+func bindEnvs() {
+	t := reflect.TypeOf(AppConfig{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("mapstructure")
+		switch {
+		case isStructMap(field.Type):
+			for name := range viper.GetStringMap(tag) {
+				configuration.BindEnvs(reflect.New(field.Type.Elem()).Interface(), tag+"."+name)
+			}
+		case field.Type.Kind() == reflect.Struct:
+			configuration.BindEnvs(reflect.New(field.Type).Interface(), tag)
+		default:
+			if err := viper.BindEnv(tag); err != nil {
+				panic("error binding env: " + err.Error())
+			}
+		}
+	}
 }
@@ -0,0 +1,60 @@
+package config
+
+import "testing"
+
+func TestMatchOrigin(t *testing.T) {
+	tests := []struct {
+		pattern string
+		origin  string
+		want    bool
+	}{
+		{"https://rizzume.com", "https://rizzume.com", true},
+		{"https://rizzume.com", "http://rizzume.com", false},
+		{"https://rizzume.com", "https://rizzume.com:8443", false},
+		{"https://rizzume.com", "https://evilrizzume.com", false},
+
+		{"https://*.perintis.app", "https://course.perintis.app", true},
+		{"https://*.perintis.app", "https://a.b.perintis.app", true},
+		{"https://*.perintis.app", "https://course-1.perintis.app", true},
+		{"https://*.perintis.app", "https://perintis.app", false},
+		{"https://*.perintis.app", "https://.perintis.app", false},
+		{"https://*.perintis.app", "http://course.perintis.app", false},
+		{"https://*.perintis.app", "https://course.perintis.app.evil.com", false},
+		{"https://*.perintis.app", "https://evil.com/.perintis.app", false},
+		{"https://*.perintis.app", "https://evil.com:443.perintis.app", false},
+		{"https://*.perintis.app", "https://user@evil.com#.perintis.app", false},
+		{"https://*.perintis.app", "https://evil.com#.perintis.app", false},
+		{"https://*.perintis.app", "https://evil.com?.perintis.app", false},
+		{"https://*.perintis.app", "https://evil\\.perintis.app", false},
+		{"https://*.perintis.app", "https://a..perintis.app", false},
+		{"https://*.perintis.app", "https://..perintis.app", false},
+
+		{"http://*.localhost:3000", "http://app.localhost:3000", true},
+		{"http://*.localhost:3000", "http://app.localhost:4000", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.origin, func(t *testing.T) {
+			if got := matchOrigin(tt.pattern, tt.origin); got != tt.want {
+				t.Errorf("matchOrigin(%q, %q) = %v, want %v", tt.pattern, tt.origin, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllowsOrigin(t *testing.T) {
+	site := SiteConfig{Urls: []string{"https://rizzume.com", "https://*.rizzume.com"}}
+	for origin, want := range map[string]bool{
+		"https://rizzume.com":     true,
+		"https://app.rizzume.com": true,
+		"https://rizzume.co":      false,
+		"":                        false,
+	} {
+		if got := site.AllowsOrigin(origin); got != want {
+			t.Errorf("AllowsOrigin(%q) = %v, want %v", origin, got, want)
+		}
+	}
+
+	if (SiteConfig{}).AllowsOrigin("https://rizzume.com") {
+		t.Error("a site without urls allows an origin")
+	}
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// RedirectTarget returns the absolute URL to send the browser to for target,
+// a URL or a path resolved against auth0.frontend_url. The URL must be on one
+// of the site's urls or the frontend url, and under one of redirect_paths. An
+// empty target is the frontend url.
+func (s SiteConfig) RedirectTarget(target string) (string, error) {
+	if target == "" {
+		return s.Auth0.FrontendUrl, nil
+	}
+	// Browsers read a backslash as a slash, making "/\evil.com" another host
+	if strings.ContainsAny(target, "\\\r\n\t") {
+		return "", errors.New("invalid characters")
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", err
+	}
+	frontend, err := url.Parse(s.Auth0.FrontendUrl)
+	if err != nil {
+		return "", err
+	}
+	if !u.IsAbs() {
+		if u.Host != "" {
+			return "", errors.New("must be a URL or a path")
+		}
+		if s.Auth0.FrontendUrl == "" {
+			return "", errors.New("paths need auth0.frontend_url")
+		}
+		u = frontend.ResolveReference(u)
+	}
+
+	if (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || u.User != nil {
+		return "", errors.New("must be an http or https URL")
+	}
+	origin := u.Scheme + "://" + u.Host
+	if !s.AllowsOrigin(origin) && origin != frontend.Scheme+"://"+frontend.Host {
+		return "", errors.New("origin " + origin + " is not a url of the site")
+	}
+	// Browsers remove dot segments, which could climb out of a prefix
+	for _, segment := range strings.Split(u.Path, "/") {
+		if segment == "." || segment == ".." {
+			return "", errors.New("path must not contain dot segments")
+		}
+	}
+	p := u.Path
+	if p == "" {
+		p = "/"
+	}
+	if !allowsPath(s.RedirectPaths, p) {
+		return "", errors.New("path " + p + " is not in redirect_paths")
+	}
+	return u.String(), nil
+}
+
+// allowsPath reports whether p is one of prefixes or below one. No prefixes
+// allow every path.
+func allowsPath(prefixes []string, p string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range prefixes {
+		prefix = strings.TrimSuffix(prefix, "/")
+		if p == prefix || strings.HasPrefix(p, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
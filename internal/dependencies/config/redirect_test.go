@@ -0,0 +1,109 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedirectTarget(t *testing.T) {
+	site := SiteConfig{
+		Urls:          []string{"https://rizzume.com", "https://*.rizzume.com"},
+		RedirectPaths: []string{"/dashboard", "/welcome/"},
+	}
+	site.Auth0.FrontendUrl = "https://app.rizzume.com/dashboard"
+
+	tests := []struct {
+		target string
+		want   string
+		err    string
+	}{
+		{target: "", want: "https://app.rizzume.com/dashboard"},
+		{target: "/dashboard", want: "https://app.rizzume.com/dashboard"},
+		{target: "/dashboard/settings?tab=1#top", want: "https://app.rizzume.com/dashboard/settings?tab=1#top"},
+		{target: "/welcome", want: "https://app.rizzume.com/welcome"},
+		{target: "settings", err: "path /settings is not in redirect_paths"},
+		{target: "https://rizzume.com/dashboard", want: "https://rizzume.com/dashboard"},
+		{target: "https://beta.rizzume.com/welcome/tour", want: "https://beta.rizzume.com/welcome/tour"},
+
+		// Other origins, including look-alikes and wildcard abuse
+		{target: "https://evil.com/dashboard", err: "is not a url of the site"},
+		{target: "https://rizzume.com.evil.com/dashboard", err: "is not a url of the site"},
+		{target: "https://rizzume.com:8443/dashboard", err: "is not a url of the site"},
+		{target: "http://rizzume.com/dashboard", err: "is not a url of the site"},
+		{target: "//evil.com/dashboard", err: "must be a URL or a path"},
+
+		// Backslashes and control characters
+		{target: "/\\evil.com/dashboard", err: "invalid characters"},
+		{target: "\\\\evil.com/dashboard", err: "invalid characters"},
+		{target: "/dashboard\r\nLocation: https://evil.com", err: "invalid characters"},
+		{target: "/dash\tboard", err: "invalid characters"},
+
+		// Dot segments climbing out of a prefix. Paths are resolved against
+		// the frontend url first, which removes plain ones.
+		{target: "/dashboard/../admin", err: "path /admin is not in redirect_paths"},
+		{target: "/dashboard/./x", want: "https://app.rizzume.com/dashboard/x"},
+		{target: "/dashboard/%2e%2e/admin", err: "dot segments"},
+		{target: "/dashboard/.%2E/admin", err: "dot segments"},
+		{target: "/dashboard%2f..%2fadmin", err: "dot segments"},
+		{target: "https://rizzume.com/dashboard/..", err: "dot segments"},
+		{target: "https://rizzume.com/dashboard/%2e%2e/admin", err: "dot segments"},
+
+		// Userinfo and other schemes
+		{target: "https://user@rizzume.com/dashboard", err: "must be an http or https URL"},
+		{target: "https://rizzume.com@evil.com/dashboard", err: "must be an http or https URL"},
+		{target: "javascript:alert(1)", err: "must be an http or https URL"},
+		{target: "data:text/html,hi", err: "must be an http or https URL"},
+
+		// Paths outside redirect_paths
+		{target: "/admin", err: "not in redirect_paths"},
+		{target: "/dashboards", err: "not in redirect_paths"},
+		{target: "https://rizzume.com", err: "path / is not in redirect_paths"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			got, err := site.RedirectTarget(tt.target)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("RedirectTarget(%q) = %q, %v, want an error containing %q", tt.target, got, err, tt.err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("RedirectTarget(%q) = %q, %v, want %q", tt.target, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedirectTargetWithoutFrontend(t *testing.T) {
+	site := SiteConfig{Urls: []string{"https://rizzume.com"}}
+
+	if got, err := site.RedirectTarget("https://rizzume.com/anywhere"); err != nil || got != "https://rizzume.com/anywhere" {
+		t.Errorf("RedirectTarget of a site url = %q, %v, want it allowed with no redirect_paths", got, err)
+	}
+	if _, err := site.RedirectTarget("/dashboard"); err == nil || !strings.Contains(err.Error(), "auth0.frontend_url") {
+		t.Errorf("RedirectTarget of a path = %v, want an error about auth0.frontend_url", err)
+	}
+}
+
+func TestAllowsPath(t *testing.T) {
+	tests := []struct {
+		prefixes []string
+		path     string
+		want     bool
+	}{
+		{nil, "/anything", true},
+		{[]string{"/app"}, "/app", true},
+		{[]string{"/app"}, "/app/x", true},
+		{[]string{"/app/"}, "/app", true},
+		{[]string{"/app"}, "/apple", false},
+		{[]string{"/app"}, "/", false},
+		{[]string{"/"}, "/", true},
+		{[]string{"/"}, "/x", true},
+	}
+	for _, tt := range tests {
+		if got := allowsPath(tt.prefixes, tt.path); got != tt.want {
+			t.Errorf("allowsPath(%q, %q) = %v, want %v", tt.prefixes, tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"context"
+	"expvar"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/cynx-io/cynx-core/src/logger"
+	"github.com/spf13/viper"
+)
+
+var (
+	reloadMu   sync.Mutex
+	validators []func(*AppConfig) error
+	listeners  []func(prev, next *AppConfig)
+
+	// Reloads counts config reloads by result, exposed on /debug/vars
+	Reloads = expvar.NewMap("config_reloads")
+)
+
+// AddValidator registers a check that a reloaded config must pass before it
+// is applied, for state that is derived from the config outside this package.
+func AddValidator(fn func(*AppConfig) error) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	validators = append(validators, fn)
+}
+
+// OnChange registers fn to be called after a reloaded config is applied.
+func OnChange(fn func(prev, next *AppConfig)) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	listeners = append(listeners, fn)
+}
+
+// Reload reads the config file again and swaps it in if it is valid. On any
+// error the current config is kept.
+func Reload() error {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	ctx := context.Background()
+	next, err := load()
+	if err != nil {
+		Reloads.Add("failure", 1)
+		logger.Error(ctx, "[CONFIG] Reload rejected, keeping current config: ", err)
+		return err
+	}
+
+	prev := Config()
+	changed := diff(prev, next)
+	if len(changed) == 0 {
+		logger.Debug(ctx, "[CONFIG] Reload found no changes")
+		return nil
+	}
+
+	current.Store(next)
+	for _, fn := range listeners {
+		fn(prev, next)
+	}
+
+	Reloads.Add("success", 1)
+	logger.Info(ctx, "[CONFIG] Reloaded config, changed: ", strings.Join(changed, ", "))
+	return nil
+}
+
+func load() (*AppConfig, error) {
+	if err := viper.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	next, err := decode()
+	if err != nil {
+		return nil, err
+	}
+	if err := resolveSecrets(next); err != nil {
+		return nil, err
+	}
+
+	if err := check(next); err != nil {
+		return nil, err
+	}
+	for _, fn := range validators {
+		if err := fn(next); err != nil {
+			return nil, err
+		}
+	}
+	return next, nil
+}
+
+// diff returns the top-level config sections that differ between a and b.
+func diff(a, b *AppConfig) []string {
+	var changed []string
+	va, vb := reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem()
+	for i := 0; i < va.NumField(); i++ {
+		if !reflect.DeepEqual(va.Field(i).Interface(), vb.Field(i).Interface()) {
+			name, _, _ := strings.Cut(va.Type().Field(i).Tag.Get("mapstructure"), ",")
+			changed = append(changed, strings.ToLower(name))
+		}
+	}
+	return changed
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// resolveSecrets replaces references in the fields tagged `secret:"true"` with
+// the value they point to, so secrets need not be stored in config.json:
+//
+//	"env:RIZZUME_CLIENT_SECRET"          the environment variable's value
+//	"file:/run/secrets/client_secret"    the file's content, without the trailing newline
+//
+// Fields whose reference cannot be resolved are cleared.
+func resolveSecrets(cfg *AppConfig) error {
+	var p problems
+	walk(reflect.ValueOf(cfg).Elem(), "", func(key string, field reflect.StructField, v reflect.Value) {
+		if !isSecret(field) {
+			return
+		}
+		value, err := resolveSecret(v.String())
+		if err != nil {
+			p.add(key, "%v", err)
+			v.SetString("")
+			return
+		}
+		v.SetString(value)
+	})
+	return errors.Join(p...)
+}
+
+func resolveSecret(ref string) (string, error) {
+	switch {
+	case strings.HasPrefix(ref, "env:"):
+		name := strings.TrimPrefix(ref, "env:")
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", errors.New("environment variable " + name + " is not set")
+		}
+		return value, nil
+	case strings.HasPrefix(ref, "file:"):
+		data, err := os.ReadFile(strings.TrimPrefix(ref, "file:"))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	default:
+		return ref, nil
+	}
+}
+
+// Redacted returns the config as it would appear in config.json, with the
+// value of every secret field replaced, for printing the effective config.
+func (c *AppConfig) Redacted() map[string]any {
+	return redact(reflect.ValueOf(c).Elem()).(map[string]any)
+}
+
+func redact(v reflect.Value) any {
+	switch v.Kind() {
+	case reflect.Struct:
+		out := make(map[string]any)
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			tag, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+			if tag == "" {
+				continue
+			}
+			if isSecret(field) && v.Field(i).String() != "" {
+				out[tag] = redacted
+				continue
+			}
+			out[tag] = redact(v.Field(i))
+		}
+		return out
+	case reflect.Map:
+		out := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out[iter.Key().String()] = redact(iter.Value())
+		}
+		return out
+	case reflect.Slice:
+		out := make([]any, v.Len())
+		for i := range out {
+			out[i] = redact(v.Index(i))
+		}
+		return out
+	default:
+		return v.Interface()
+	}
+}
+
+// walk calls fn for every non-struct field under v, with its dotted key. The
+// entries of slices and maps of structs, such as sites, are walked too.
+func walk(v reflect.Value, prefix string, fn func(key string, field reflect.StructField, v reflect.Value)) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		tag, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if tag == "" {
+			continue
+		}
+
+		key := prefix + tag
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			walk(v.Field(i), key+".", fn)
+		case field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Struct:
+			for j := 0; j < v.Field(i).Len(); j++ {
+				walk(v.Field(i).Index(j), key+"["+strconv.Itoa(j)+"].", fn)
+			}
+		case isStructMap(field.Type):
+			// Map entries are not addressable, so walk a copy and store it back
+			iter := v.Field(i).MapRange()
+			for iter.Next() {
+				entry := reflect.New(field.Type.Elem()).Elem()
+				entry.Set(iter.Value())
+				walk(entry, key+"."+iter.Key().String()+".", fn)
+				v.Field(i).SetMapIndex(iter.Key(), entry)
+			}
+		default:
+			fn(key, field, v.Field(i))
+		}
+	}
+}
+
+func isStructMap(t reflect.Type) bool {
+	return t.Kind() == reflect.Map && t.Key().Kind() == reflect.String && t.Elem().Kind() == reflect.Struct
+}
+
+func isSecret(field reflect.StructField) bool {
+	return field.Tag.Get("secret") == "true" && field.Type.Kind() == reflect.String
+}
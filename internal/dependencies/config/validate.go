@@ -0,0 +1,385 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cynx-io/janus-gateway/internal/constant"
+	"github.com/cynx-io/janus-gateway/internal/policy"
+	"github.com/spf13/viper"
+)
+
+// servicePattern matches a services entry: "*", a package, a service or a
+// method.
+var servicePattern = regexp.MustCompile(`^(\*|[A-Za-z0-9_]+(\.[A-Za-z0-9_]+)*(/[A-Za-z0-9_]+)?)$`)
+
+// wildcardPattern matches an origin with a wildcard subdomain.
+var wildcardPattern = regexp.MustCompile(`^[a-z][a-z0-9+.-]*://\*\.[^*]+$`)
+
+var sha256Pattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// siteKeyPattern keeps site keys usable in environment variable names.
+var siteKeyPattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// problems collects validation errors keyed by config path, so every problem
+// is reported at once instead of failing on the first.
+type problems []error
+
+func (p *problems) add(key string, format string, args ...any) {
+	*p = append(*p, errors.New(key+": "+fmt.Sprintf(format, args...)))
+}
+
+// Check validates the active config, including keys in the config file that
+// do not map to any setting and secret references that could not be resolved.
+func Check() error {
+	return errors.Join(unresolved, check(Config()))
+}
+
+func check(cfg *AppConfig) error {
+	return errors.Join(cfg.Validate(), unknownKeys(viper.AllKeys()))
+}
+
+// Validate checks the config for values the gateway cannot run with.
+func (c *AppConfig) Validate() error {
+	var p problems
+
+	if c.App.Port < 1 || c.App.Port > 65535 {
+		p.add("app.port", "must be between 1 and 65535, got %d", c.App.Port)
+	}
+
+	if c.Auth0.Domain == "" {
+		p.add("auth0.domain", "is required")
+	} else if strings.Contains(c.Auth0.Domain, "/") {
+		p.add("auth0.domain", "must be a bare host such as tenant.auth0.com, got %q", c.Auth0.Domain)
+	}
+	if c.Auth0.RefreshGrace < 0 {
+		p.add("auth0.refresh_grace", "must not be negative")
+	}
+
+	// Signs gateway refresh tokens
+	if len(c.JWT.Secret) < 32 {
+		p.add("jwt.secret", "must be at least 32 bytes, got %d", len(c.JWT.Secret))
+	}
+	if c.JWT.ExpiresInHours < 1 {
+		p.add("jwt.expiresInHours", "must be at least 1")
+	}
+	if c.JWT.RefreshExpiresInHours < 0 {
+		p.add("jwt.refresh_expires_in_hours", "must not be negative")
+	}
+	kids := make(map[string]bool)
+	for i, key := range c.JWT.Keys {
+		prefix := "jwt.keys[" + strconv.Itoa(i) + "]"
+		if key.Kid == "" {
+			p.add(prefix+".kid", "is required")
+		} else if kids[key.Kid] {
+			p.add(prefix+".kid", "duplicate kid %q", key.Kid)
+		}
+		kids[key.Kid] = true
+
+		if _, err := key.Signer(); err != nil {
+			p.add(prefix+".private_key", "%v", err)
+		}
+	}
+
+	switch c.Session.Store {
+	case "", "cookie", "memory":
+	case "file":
+		if c.Session.Dir == "" {
+			p.add("session.dir", "is required for the file store")
+		}
+	case "redis":
+		if u, err := url.Parse(c.Session.RedisUrl); err != nil || (u.Scheme != "redis" && u.Scheme != "rediss") || u.Host == "" {
+			p.add("session.redis_url", "must be a redis:// or rediss:// URL")
+		}
+	default:
+		p.add("session.store", "must be cookie, memory, file or redis, got %q", c.Session.Store)
+	}
+	if c.Session.Ttl < 0 {
+		p.add("session.ttl", "must not be negative")
+	}
+	if c.Session.GcInterval < 0 {
+		p.add("session.gc_interval", "must not be negative")
+	}
+
+	if c.Elastic.Url != "" {
+		checkURL(&p, "elastic.url", c.Elastic.Url)
+	}
+
+	for _, pkg := range upstreams {
+		key := pkg + ".url"
+		target := c.UpstreamUrl(pkg)
+		if target == "" {
+			p.add(key, "is required")
+			continue
+		}
+		if err := checkTarget(target); err != nil {
+			p.add(key, "%v", err)
+		}
+	}
+
+	if len(c.Sites) == 0 {
+		p.add("sites", "at least one site is required")
+	}
+
+	origins := make(map[string]constant.SiteKey)
+	c.Sites.Iterate(func(key constant.SiteKey, site SiteConfig) {
+		prefix := "sites." + string(key)
+		if !siteKeyPattern.MatchString(string(key)) {
+			p.add(prefix, "site key must contain only lowercase letters, digits and underscores")
+		}
+
+		if site.Auth0.ClientId == "" {
+			p.add(prefix+".auth0.client_id", "is required")
+		}
+		if site.Auth0.ClientSecret == "" {
+			p.add(prefix+".auth0.client_secret", "is required")
+		}
+		if len(site.Auth0.SessionSecret) < 32 {
+			p.add(prefix+".auth0.session_secret", "must be at least 32 bytes, got %d", len(site.Auth0.SessionSecret))
+		}
+		checkURL(&p, prefix+".auth0.callback_url", site.Auth0.CallbackUrl)
+		checkURL(&p, prefix+".auth0.frontend_url", site.Auth0.FrontendUrl)
+		checkURL(&p, prefix+".api_url", site.ApiUrl)
+
+		for i, origin := range site.Urls {
+			originKey := prefix + ".urls[" + strconv.Itoa(i) + "]"
+			if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" || u.Path != "" {
+				p.add(originKey, "must be an origin such as https://example.com, got %q", origin)
+				continue
+			}
+			if strings.Contains(origin, "*") && !wildcardPattern.MatchString(origin) {
+				p.add(originKey, "wildcard must be a leading subdomain such as https://*.example.com, got %q", origin)
+				continue
+			}
+			if other, ok := origins[origin]; ok {
+				p.add(originKey, "origin %q is already used by site %s", origin, other)
+				continue
+			}
+			origins[origin] = key
+		}
+
+		for i, redirectPath := range site.RedirectPaths {
+			if !strings.HasPrefix(redirectPath, "/") {
+				p.add(prefix+".redirect_paths["+strconv.Itoa(i)+"]", "must be a path starting with /, got %q", redirectPath)
+			}
+		}
+
+		// Transferred sessions have no Auth0 tokens and rely on the store to
+		// expire them, and replicas must share the transfer codes
+		if len(site.SsoTargets) > 0 && c.Session.Store != "file" && c.Session.Store != "redis" {
+			p.add(prefix+".sso_targets", "needs a shared session store, set session.store to file or redis")
+		}
+		for i, target := range site.SsoTargets {
+			targetKey := prefix + ".sso_targets[" + strconv.Itoa(i) + "]"
+			if target == key {
+				p.add(targetKey, "must be another site")
+			} else if _, ok := c.Sites[target]; !ok {
+				p.add(targetKey, "unknown site %q", target)
+			} else if c.Sites[target].ApiUrl == "" {
+				p.add(targetKey, "site %q needs an api_url to redeem transfer codes", target)
+			}
+		}
+
+		if site.CORS.MaxAge < 0 {
+			p.add(prefix+".cors.max_age", "must not be negative")
+		}
+		for i, method := range site.CORS.Methods {
+			if !isHTTPMethod(method) && !strings.EqualFold(method, http.MethodOptions) {
+				p.add(prefix+".cors.methods["+strconv.Itoa(i)+"]", "must be an HTTP method, got %q", method)
+			}
+		}
+
+		for i, entry := range site.Services {
+			if !servicePattern.MatchString(entry) {
+				p.add(prefix+".services["+strconv.Itoa(i)+"]", "must be \"*\", a package, a service or a Service/Method, got %q", entry)
+			}
+		}
+	})
+
+	for i, mapping := range c.Rest {
+		key := "rest[" + strconv.Itoa(i) + "]"
+		if !isHTTPMethod(mapping.Method) {
+			p.add(key+".method", "must be an HTTP method, got %q", mapping.Method)
+		}
+		if !strings.HasPrefix(mapping.Path, "/") {
+			p.add(key+".path", "must be an absolute path, got %q", mapping.Path)
+		}
+		if mapping.Rpc == "" {
+			p.add(key+".rpc", "is required")
+		}
+	}
+
+	ids := make(map[string]bool)
+	for i, key := range c.ApiKeys {
+		prefix := "api_keys[" + strconv.Itoa(i) + "]"
+		if key.Id == "" {
+			p.add(prefix+".id", "is required")
+		} else if ids[key.Id] {
+			p.add(prefix+".id", "duplicate key id %q", key.Id)
+		}
+		ids[key.Id] = true
+
+		if !sha256Pattern.MatchString(key.Hash) {
+			p.add(prefix+".hash", "must be the hex-encoded SHA-256 of the key")
+		}
+		if _, ok := c.Sites[key.Site]; !ok {
+			p.add(prefix+".site", "unknown site %q", key.Site)
+		}
+		for j, entry := range key.Routes {
+			if !servicePattern.MatchString(entry) {
+				p.add(prefix+".routes["+strconv.Itoa(j)+"]", "must be \"*\", a package, a service or a Service/Method, got %q", entry)
+			}
+		}
+		if key.RateLimit < 0 {
+			p.add(prefix+".rate_limit", "must not be negative")
+		}
+	}
+
+	names := make(map[string]bool)
+	for i, policyConfig := range c.Policies {
+		prefix := "policies[" + strconv.Itoa(i) + "]"
+		if policyConfig.Name == "" {
+			p.add(prefix+".name", "is required")
+		} else if names[policyConfig.Name] {
+			p.add(prefix+".name", "duplicate policy name %q", policyConfig.Name)
+		}
+		names[policyConfig.Name] = true
+
+		if len(policyConfig.Routes) == 0 {
+			p.add(prefix+".routes", "at least one route is required, use \"*\" for every route")
+		}
+		for j, entry := range policyConfig.Routes {
+			if !servicePattern.MatchString(entry) {
+				p.add(prefix+".routes["+strconv.Itoa(j)+"]", "must be \"*\", a package, a service or a Service/Method, got %q", entry)
+			}
+		}
+		if _, err := policy.Compile(policyConfig.Allow); err != nil {
+			p.add(prefix+".allow", "%v", err)
+		}
+	}
+
+	if c.Batch.MaxItems < 0 {
+		p.add("batch.max_items", "must not be negative")
+	}
+	if c.Batch.MaxConcurrency < 0 {
+		p.add("batch.max_concurrency", "must not be negative")
+	}
+
+	return errors.Join(p...)
+}
+
+func checkURL(p *problems, key string, raw string) {
+	if raw == "" {
+		p.add(key, "is required")
+		return
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		p.add(key, "must be an absolute URL, got %q", raw)
+	}
+}
+
+// checkTarget accepts a gRPC target, either host:port or a scheme URI such
+// as dns:///host:port.
+func checkTarget(target string) error {
+	if strings.Contains(target, "://") {
+		if _, err := url.Parse(target); err != nil {
+			return fmt.Errorf("malformed target %q: %v", target, err)
+		}
+		return nil
+	}
+	host, port, err := net.SplitHostPort(target)
+	if err != nil || host == "" {
+		return fmt.Errorf("must be host:port, got %q", target)
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return fmt.Errorf("invalid port in %q", target)
+	}
+	return nil
+}
+
+func isHTTPMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// unknownKeys reports keys that do not map to a field of AppConfig, which
+// are usually typos and would otherwise be silently ignored.
+func unknownKeys(keys []string) error {
+	known := make(map[string]bool)
+	collectKeys(reflect.TypeOf(AppConfig{}), "", known)
+
+	var p problems
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !isKnown(key, known) {
+			p.add(key, "unknown key")
+		}
+	}
+	return errors.Join(p...)
+}
+
+// collectKeys adds the lowercased key of every field under t to known, mapped
+// to whether it is a leaf. Struct fields are expanded, and maps of structs are
+// expanded under a "*" segment; other fields, such as slices, are leaves and
+// may have arbitrary keys below them.
+func collectKeys(t reflect.Type, prefix string, known map[string]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if tag == "" {
+			continue
+		}
+
+		key := strings.ToLower(prefix + tag)
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			known[key] = false
+			collectKeys(field.Type, key+".", known)
+		case isStructMap(field.Type):
+			known[key] = false
+			known[key+".*"] = false
+			collectKeys(field.Type.Elem(), key+".*.", known)
+		default:
+			known[key] = true
+		}
+	}
+}
+
+// isKnown reports whether key is a known field or lies below a known leaf.
+func isKnown(key string, known map[string]bool) bool {
+	// Replace map keys with "*", e.g. sites.rizzume.urls -> sites.*.urls
+	parts := strings.Split(key, ".")
+	for i := 1; i < len(parts); i++ {
+		if _, ok := known[strings.Join(parts[:i], ".")+".*"]; ok {
+			parts[i] = "*"
+		}
+	}
+	key = strings.Join(parts, ".")
+
+	if _, ok := known[key]; ok {
+		return true
+	}
+	for {
+		if known[key] {
+			return true
+		}
+		i := strings.LastIndex(key, ".")
+		if i < 0 {
+			return false
+		}
+		key = key[:i]
+	}
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/cynx-io/cynx-core/src/logger"
+	"github.com/fsnotify/fsnotify"
+)
+
+// settle is how long to wait for further writes before reloading, since
+// editors and config-map updates often touch the file several times.
+const settle = 250 * time.Millisecond
+
+// Watch reloads the config whenever the config file changes. The directory is
+// watched rather than the file, so replacing the file by rename (editors,
+// Kubernetes config maps) is picked up too.
+func Watch() {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		panic("Failed to create config watcher: " + err.Error())
+	}
+
+	file, err := filepath.Abs(path)
+	if err != nil {
+		panic("Failed to resolve config path: " + err.Error())
+	}
+	dir := filepath.Dir(file)
+	if err := watcher.Add(dir); err != nil {
+		panic("Failed to watch config directory " + dir + ": " + err.Error())
+	}
+
+	go func() {
+		var timer *time.Timer
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if !isConfigEvent(event, file) {
+					continue
+				}
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(settle, func() {
+					_ = Reload()
+				})
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logger.Error(context.Background(), "[CONFIG] Watcher error: ", err)
+			}
+		}
+	}()
+}
+
+func isConfigEvent(event fsnotify.Event, file string) bool {
+	if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Rename) {
+		return false
+	}
+	// Config maps swap a "..data" symlink instead of writing the file
+	name := filepath.Clean(event.Name)
+	return name == file || filepath.Base(name) == "..data"
+}
@@ -0,0 +1,96 @@
+package sessionstore
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// Adder is a Backend that can save an entry only if there is none, in one
+// step.
+type Adder interface {
+	Add(ctx context.Context, key string, data []byte, ttl time.Duration) (bool, error)
+}
+
+var (
+	_ Adder = (*Memory)(nil)
+	_ Adder = (*File)(nil)
+	_ Adder = (*CacheBackend)(nil)
+)
+
+// Add saves data under key unless an entry that has not expired is already
+// there, and reports whether it did, so only one of concurrent callers wins.
+// It can serve as a lock that expires after ttl. Backends that are not an
+// Adder fall back to a load followed by a save.
+func Add(ctx context.Context, b Backend, key string, data []byte, ttl time.Duration) (bool, error) {
+	if adder, ok := b.(Adder); ok {
+		return adder.Add(ctx, key, data, ttl)
+	}
+
+	if _, _, err := b.Load(ctx, key); !errors.Is(err, ErrNotFound) {
+		return false, err
+	}
+	return true, b.Save(ctx, key, data, ttl)
+}
+
+func (m *Memory) Add(_ context.Context, key string, data []byte, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if entry, ok := m.entries[key]; ok && !time.Now().After(entry.expiresAt) {
+		return false, nil
+	}
+	m.entries[key] = memoryEntry{data: data, expiresAt: time.Now().Add(ttl)}
+	return true, nil
+}
+
+// Add links a temporary file into place, which fails if the entry exists.
+// An expired entry is removed first; two callers finding the same expired
+// entry at once may both win.
+func (f *File) Add(_ context.Context, key string, data []byte, ttl time.Duration) (bool, error) {
+	tmp, err := os.CreateTemp(f.dir, ".tmp-*")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(encodeEntry(data, time.Now().Add(ttl))); err != nil {
+		_ = tmp.Close()
+		return false, err
+	}
+	if err := tmp.Close(); err != nil {
+		return false, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := os.Link(tmp.Name(), f.path(key))
+		if err == nil {
+			return true, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return false, err
+		}
+		if attempt > 0 || !expired(f.path(key), time.Now()) {
+			return false, nil
+		}
+		if err := os.Remove(f.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return false, err
+		}
+	}
+}
+
+// Add uses the SetNX of the cache, if it has one.
+func (c *CacheBackend) Add(ctx context.Context, key string, data []byte, ttl time.Duration) (bool, error) {
+	setNX, ok := c.cache.(interface {
+		SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+	})
+	if !ok {
+		if _, _, err := c.Load(ctx, key); !errors.Is(err, ErrNotFound) {
+			return false, err
+		}
+		return true, c.Save(ctx, key, data, ttl)
+	}
+	return setNX.SetNX(ctx, key, encodeEntry(data, time.Now().Add(ttl)), ttl)
+}
@@ -0,0 +1,73 @@
+package sessionstore
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testAdd checks Add on b. Entries saved already expired are only replaced
+// when expiredEntries, since a cache expires entries itself.
+func testAdd(t *testing.T, b Backend, expiredEntries bool) {
+	ctx := context.Background()
+
+	// Only one of concurrent callers wins
+	var wins atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			added, err := Add(ctx, b, "lock", []byte("x"), time.Hour)
+			if err != nil {
+				t.Errorf("Add failed: %v", err)
+			}
+			if added {
+				wins.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := wins.Load(); n != 1 {
+		t.Errorf("%d concurrent Adds won, want 1", n)
+	}
+
+	if err := b.Delete(ctx, "lock"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if added, err := Add(ctx, b, "lock", []byte("y"), time.Hour); err != nil || !added {
+		t.Errorf("Add after Delete = %v, %v, want true", added, err)
+	}
+
+	if !expiredEntries {
+		return
+	}
+	if err := b.Save(ctx, "expired", []byte("x"), -time.Second); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if added, err := Add(ctx, b, "expired", []byte("y"), time.Hour); err != nil || !added {
+		t.Errorf("Add over an expired entry = %v, %v, want true", added, err)
+	}
+	if data, _, err := b.Load(ctx, "expired"); err != nil || string(data) != "y" {
+		t.Errorf("Load after Add = %q, %v, want y", data, err)
+	}
+}
+
+func TestMemoryAdd(t *testing.T) {
+	testAdd(t, NewMemory(), true)
+}
+
+func TestFileAdd(t *testing.T) {
+	f, err := NewFile(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFile failed: %v", err)
+	}
+	testAdd(t, f, true)
+}
+
+func TestCacheBackendAdd(t *testing.T) {
+	r, _ := newTestRedis(t)
+	testAdd(t, NewCacheBackend(r), false)
+}
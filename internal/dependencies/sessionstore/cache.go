@@ -0,0 +1,44 @@
+package sessionstore
+
+import (
+	"context"
+	"time"
+)
+
+// Cache is a shared key-value cache with expiring entries and sets, such as
+// Redis. Get returns ErrNotFound for a missing key. SAdd adds a member to a
+// set and expires the whole set after ttl.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Del(ctx context.Context, key string) error
+	SAdd(ctx context.Context, key string, member string, ttl time.Duration) error
+	SRem(ctx context.Context, key string, members ...string) error
+	SMembers(ctx context.Context, key string) ([]string, error)
+}
+
+// CacheBackend adapts a Cache to a Backend. Replicas sharing the cache share
+// sessions, and the cache expires them.
+type CacheBackend struct {
+	cache Cache
+}
+
+func NewCacheBackend(cache Cache) *CacheBackend {
+	return &CacheBackend{cache: cache}
+}
+
+func (c *CacheBackend) Load(ctx context.Context, key string) ([]byte, time.Time, error) {
+	entry, err := c.cache.Get(ctx, key)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return decodeEntry(entry)
+}
+
+func (c *CacheBackend) Save(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	return c.cache.Set(ctx, key, encodeEntry(data, time.Now().Add(ttl)), ttl)
+}
+
+func (c *CacheBackend) Delete(ctx context.Context, key string) error {
+	return c.cache.Del(ctx, key)
+}
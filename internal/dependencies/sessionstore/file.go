@@ -0,0 +1,113 @@
+package sessionstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cynx-io/cynx-core/src/logger"
+)
+
+// File keeps each session in its own file under a directory, which survives
+// restarts and can be shared by replicas on the same volume.
+type File struct {
+	dir string
+}
+
+func NewFile(dir string) (*File, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &File{dir: dir}, nil
+}
+
+// path names the file by a hash of the key, which keeps ids out of file
+// names and any key a valid file name.
+func (f *File) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(f.dir, hex.EncodeToString(sum[:])+".session")
+}
+
+func (f *File) Load(_ context.Context, key string) ([]byte, time.Time, error) {
+	entry, err := os.ReadFile(f.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, time.Time{}, ErrNotFound
+	}
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	data, expiresAt, err := decodeEntry(entry)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if time.Now().After(expiresAt) {
+		return nil, time.Time{}, ErrNotFound
+	}
+	return data, expiresAt, nil
+}
+
+// Save writes the session to a temporary file and renames it into place, so
+// readers never see a partial session.
+func (f *File) Save(_ context.Context, key string, data []byte, ttl time.Duration) error {
+	tmp, err := os.CreateTemp(f.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(encodeEntry(data, time.Now().Add(ttl))); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path(key))
+}
+
+func (f *File) Delete(_ context.Context, key string) error {
+	err := os.Remove(f.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// GC removes the session files and set members that expired before now.
+func (f *File) GC(now time.Time) {
+	defer f.gcSets(now)
+
+	paths, err := filepath.Glob(filepath.Join(f.dir, "*.session"))
+	if err != nil {
+		return
+	}
+	for _, path := range paths {
+		if expired(path, now) {
+			if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				logger.Error(context.Background(), "[SESSION] Failed to remove expired session: ", err)
+			}
+		}
+	}
+}
+
+func expired(path string, now time.Time) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return true
+	}
+	_, expiresAt, err := decodeEntry(header)
+	return err != nil || now.After(expiresAt)
+}
@@ -0,0 +1,64 @@
+package sessionstore
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	data      []byte
+	expiresAt time.Time
+}
+
+// Memory keeps sessions in process memory. Sessions are lost on restart and
+// not shared between replicas.
+type Memory struct {
+	mu      sync.RWMutex
+	entries map[string]memoryEntry
+	sets    map[string]memorySet
+}
+
+func NewMemory() *Memory {
+	return &Memory{entries: make(map[string]memoryEntry), sets: make(map[string]memorySet)}
+}
+
+func (m *Memory) Load(_ context.Context, key string) ([]byte, time.Time, error) {
+	m.mu.RLock()
+	entry, ok := m.entries[key]
+	m.mu.RUnlock()
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, time.Time{}, ErrNotFound
+	}
+	return entry.data, entry.expiresAt, nil
+}
+
+func (m *Memory) Save(_ context.Context, key string, data []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = memoryEntry{data: data, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (m *Memory) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key)
+	return nil
+}
+
+// GC removes the sessions and sets that expired before now.
+func (m *Memory) GC(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, entry := range m.entries {
+		if now.After(entry.expiresAt) {
+			delete(m.entries, key)
+		}
+	}
+	for key, set := range m.sets {
+		if now.After(set.expiresAt) {
+			delete(m.sets, key)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package sessionstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newTestRedis(t *testing.T) (*Redis, *miniredis.Miniredis) {
+	t.Helper()
+	m := miniredis.RunT(t)
+	r, err := NewRedis("redis://" + m.Addr() + "/0")
+	if err != nil {
+		t.Fatalf("NewRedis failed: %v", err)
+	}
+	t.Cleanup(func() { _ = r.client.Close() })
+	return r, m
+}
+
+func TestRedis(t *testing.T) {
+	r, m := newTestRedis(t)
+	ctx := context.Background()
+
+	if _, err := r.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get of a missing key = %v, want ErrNotFound", err)
+	}
+
+	value := []byte("binary\x00\r\nvalue")
+	if err := r.Set(ctx, "k", value, 1500*time.Millisecond); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if got := m.TTL("k"); got != 1500*time.Millisecond {
+		t.Errorf("Set sent ttl %v, want 1.5s", got)
+	}
+	if got, err := r.Get(ctx, "k"); err != nil || !bytes.Equal(got, value) {
+		t.Errorf("Get = %q, %v, want %q", got, err, value)
+	}
+
+	if got, err := r.GetDel(ctx, "k"); err != nil || !bytes.Equal(got, value) {
+		t.Errorf("GetDel = %q, %v, want %q", got, err, value)
+	}
+	if _, err := r.GetDel(ctx, "k"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetDel after GetDel = %v, want ErrNotFound", err)
+	}
+
+	if err := r.Set(ctx, "d", []byte("x"), time.Minute); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := r.Del(ctx, "d"); err != nil {
+		t.Errorf("Del failed: %v", err)
+	}
+	if _, err := r.Get(ctx, "d"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Del = %v, want ErrNotFound", err)
+	}
+}
+
+func TestRedisSets(t *testing.T) {
+	r, m := newTestRedis(t)
+	ctx := context.Background()
+
+	if members, err := r.SMembers(ctx, "s"); err != nil || len(members) != 0 {
+		t.Errorf("SMembers of a missing set = %q, %v, want none", members, err)
+	}
+	for _, member := range []string{"b", "a", "c"} {
+		if err := r.SAdd(ctx, "s", member, 2*time.Second); err != nil {
+			t.Fatalf("SAdd failed: %v", err)
+		}
+	}
+	if got := m.TTL("s"); got != 2*time.Second {
+		t.Errorf("SAdd sent ttl %v, want 2s", got)
+	}
+	if err := r.SRem(ctx, "s", "a", "c"); err != nil {
+		t.Fatalf("SRem failed: %v", err)
+	}
+	if members, err := r.SMembers(ctx, "s"); err != nil || !reflect.DeepEqual(members, []string{"b"}) {
+		t.Errorf("SMembers = %q, %v, want [b]", members, err)
+	}
+}
+
+func TestNewRedis(t *testing.T) {
+	for _, url := range []string{"http://cache", "redis://cache/x", "redis://%zz"} {
+		if _, err := NewRedis(url); err == nil {
+			t.Errorf("NewRedis(%q) succeeded, want an error", url)
+		}
+	}
+}
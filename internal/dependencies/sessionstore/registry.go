@@ -0,0 +1,126 @@
+package sessionstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// indexTtl bounds how long the session index of a user is kept after their
+// last login or session listing.
+const indexTtl = 365 * 24 * time.Hour
+
+// Entry is an active session of a user.
+type Entry struct {
+	Key    string
+	Values map[interface{}]interface{}
+}
+
+// ID is the public id of the session, which identifies it without revealing
+// the session id itself.
+func (e Entry) ID() string {
+	return PublicID(e.Key)
+}
+
+func PublicID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:12])
+}
+
+func indexKey(userID string) string {
+	return "user:" + userID + ":sessions"
+}
+
+// Register adds the session stored under key to the sessions of userID.
+func Register(ctx context.Context, userID string, key string) error {
+	sets, err := registry()
+	if err != nil {
+		return err
+	}
+	return sets.AddMember(ctx, indexKey(userID), key, indexTtl)
+}
+
+// Sessions returns the active sessions of userID, in no particular order,
+// and drops expired ones from the index.
+func Sessions(ctx context.Context, userID string) ([]Entry, error) {
+	sets, err := registry()
+	if err != nil {
+		return nil, err
+	}
+	entries, stale, err := load(ctx, sets, userID)
+	if err != nil {
+		return nil, err
+	}
+	return entries, sets.RemoveMembers(ctx, indexKey(userID), stale...)
+}
+
+// Revoke deletes the sessions of userID with the given public ids, or all of
+// them if no id is given, and returns how many were deleted.
+func Revoke(ctx context.Context, userID string, ids ...string) (int, error) {
+	sets, err := registry()
+	if err != nil {
+		return 0, err
+	}
+	entries, stale, err := load(ctx, sets, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	revoke := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		revoke[id] = true
+	}
+
+	revoked := 0
+	for _, entry := range entries {
+		if len(ids) > 0 && !revoke[entry.ID()] {
+			continue
+		}
+		if err := backend.Delete(ctx, entry.Key); err != nil {
+			return revoked, err
+		}
+		stale = append(stale, entry.Key)
+		revoked++
+	}
+	return revoked, sets.RemoveMembers(ctx, indexKey(userID), stale...)
+}
+
+func registry() (Sets, error) {
+	sets, ok := backend.(Sets)
+	if !ok {
+		return nil, errors.New("session store cannot index sessions")
+	}
+	return sets, nil
+}
+
+// load returns the sessions in the index of userID that still exist, and the
+// keys of those that do not.
+func load(ctx context.Context, sets Sets, userID string) ([]Entry, []string, error) {
+	keys, err := sets.Members(ctx, indexKey(userID))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var entries []Entry
+	var stale []string
+	for _, key := range keys {
+		data, _, err := backend.Load(ctx, key)
+		if errors.Is(err, ErrNotFound) {
+			stale = append(stale, key)
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		entry := Entry{Key: key}
+		if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&entry.Values); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, stale, nil
+}
@@ -0,0 +1,62 @@
+package sessionstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"testing"
+	"time"
+)
+
+func gobValues(t *testing.T, values map[interface{}]interface{}) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(values); err != nil {
+		t.Fatalf("Failed to encode values: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestRegistry(t *testing.T) {
+	prev := backend
+	backend = NewMemory()
+	t.Cleanup(func() { backend = prev })
+	ctx := context.Background()
+
+	for _, key := range []string{"session:a:1", "session:b:2", "session:a:3"} {
+		if err := backend.Save(ctx, key, gobValues(t, map[interface{}]interface{}{"user_id": "7"}), time.Hour); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+		if err := Register(ctx, "7", key); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+	}
+	if err := Register(ctx, "7", "session:a:gone"); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	entries, err := Sessions(ctx, "7")
+	if err != nil || len(entries) != 3 {
+		t.Fatalf("Sessions = %d entries, %v, want 3", len(entries), err)
+	}
+	if got := members(t, backend.(Sets), indexKey("7")); len(got) != 3 {
+		t.Errorf("index = %q, want the missing session dropped", got)
+	}
+
+	revoked, err := Revoke(ctx, "7", PublicID("session:b:2"))
+	if err != nil || revoked != 1 {
+		t.Fatalf("Revoke of one id = %d, %v, want 1", revoked, err)
+	}
+	if _, _, err := backend.Load(ctx, "session:b:2"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("revoked session still loads: %v", err)
+	}
+
+	revoked, err = Revoke(ctx, "7")
+	if err != nil || revoked != 2 {
+		t.Fatalf("Revoke of all = %d, %v, want 2", revoked, err)
+	}
+	if got := members(t, backend.(Sets), indexKey("7")); len(got) != 0 {
+		t.Errorf("index after Revoke of all = %q, want empty", got)
+	}
+}
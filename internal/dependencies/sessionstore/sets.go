@@ -0,0 +1,222 @@
+package sessionstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cynx-io/cynx-core/src/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+// Sets is a Backend that also keeps sets of strings, which are updated one
+// member at a time so concurrent updates from replicas do not overwrite
+// each other.
+type Sets interface {
+	// AddMember adds member to the set under key, keeping it for at least ttl.
+	AddMember(ctx context.Context, key string, member string, ttl time.Duration) error
+	RemoveMembers(ctx context.Context, key string, members ...string) error
+	// Members returns the members of the set under key, in no particular order.
+	Members(ctx context.Context, key string) ([]string, error)
+}
+
+var (
+	_ Sets = (*Memory)(nil)
+	_ Sets = (*File)(nil)
+	_ Sets = (*CacheBackend)(nil)
+)
+
+type memorySet struct {
+	members   map[string]struct{}
+	expiresAt time.Time
+}
+
+func (m *Memory) AddMember(_ context.Context, key string, member string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	set, ok := m.sets[key]
+	if !ok || time.Now().After(set.expiresAt) {
+		set = memorySet{members: make(map[string]struct{})}
+	}
+	set.members[member] = struct{}{}
+	set.expiresAt = time.Now().Add(ttl)
+	m.sets[key] = set
+	return nil
+}
+
+func (m *Memory) RemoveMembers(_ context.Context, key string, members ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	set, ok := m.sets[key]
+	if !ok {
+		return nil
+	}
+	for _, member := range members {
+		delete(set.members, member)
+	}
+	if len(set.members) == 0 {
+		delete(m.sets, key)
+	}
+	return nil
+}
+
+func (m *Memory) Members(_ context.Context, key string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	set, ok := m.sets[key]
+	if !ok || time.Now().After(set.expiresAt) {
+		return nil, nil
+	}
+	members := make([]string, 0, len(set.members))
+	for member := range set.members {
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+// setDir is the directory of a set. Each member is a file in it, so members
+// are added and removed with a single rename or unlink.
+func (f *File) setDir(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(f.dir, hex.EncodeToString(sum[:])+".set")
+}
+
+func (f *File) memberPath(key string, member string) string {
+	sum := sha256.Sum256([]byte(member))
+	return filepath.Join(f.setDir(key), hex.EncodeToString(sum[:]))
+}
+
+// AddMember writes the member to a temporary file and renames it into the
+// set, creating the set again if GC removed it meanwhile.
+func (f *File) AddMember(_ context.Context, key string, member string, ttl time.Duration) error {
+	tmp, err := os.CreateTemp(f.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(encodeEntry([]byte(member), time.Now().Add(ttl))); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := os.MkdirAll(f.setDir(key), 0o700); err != nil {
+			return err
+		}
+		err := os.Rename(tmp.Name(), f.memberPath(key, member))
+		if !errors.Is(err, fs.ErrNotExist) || attempt > 0 {
+			return err
+		}
+	}
+}
+
+func (f *File) RemoveMembers(_ context.Context, key string, members ...string) error {
+	for _, member := range members {
+		if err := os.Remove(f.memberPath(key, member)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *File) Members(_ context.Context, key string) ([]string, error) {
+	files, err := os.ReadDir(f.setDir(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var members []string
+	for _, file := range files {
+		entry, err := os.ReadFile(filepath.Join(f.setDir(key), file.Name()))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		member, expiresAt, err := decodeEntry(entry)
+		if err != nil || now.After(expiresAt) {
+			continue
+		}
+		members = append(members, string(member))
+	}
+	return members, nil
+}
+
+// gcSets removes the set members that expired before now, and sets left
+// empty.
+func (f *File) gcSets(now time.Time) {
+	dirs, err := filepath.Glob(filepath.Join(f.dir, "*.set"))
+	if err != nil {
+		return
+	}
+	for _, dir := range dirs {
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, file := range files {
+			path := filepath.Join(dir, file.Name())
+			if expired(path, now) {
+				if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+					logger.Error(context.Background(), "[SESSION] Failed to remove expired set member: ", err)
+				}
+			}
+		}
+		// Fails while the set has members
+		_ = os.Remove(dir)
+	}
+}
+
+func (c *CacheBackend) AddMember(ctx context.Context, key string, member string, ttl time.Duration) error {
+	return c.cache.SAdd(ctx, key, member, ttl)
+}
+
+func (c *CacheBackend) RemoveMembers(ctx context.Context, key string, members ...string) error {
+	if len(members) == 0 {
+		return nil
+	}
+	return c.cache.SRem(ctx, key, members...)
+}
+
+func (c *CacheBackend) Members(ctx context.Context, key string) ([]string, error) {
+	return c.cache.SMembers(ctx, key)
+}
+
+// SAdd adds the member and extends the ttl of the set in one transaction.
+func (r *Redis) SAdd(ctx context.Context, key string, member string, ttl time.Duration) error {
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SAdd(ctx, key, member)
+		pipe.PExpire(ctx, key, ttl)
+		return nil
+	})
+	return err
+}
+
+func (r *Redis) SRem(ctx context.Context, key string, members ...string) error {
+	args := make([]any, len(members))
+	for i, member := range members {
+		args[i] = member
+	}
+	return r.client.SRem(ctx, key, args...).Err()
+}
+
+func (r *Redis) SMembers(ctx context.Context, key string) ([]string, error) {
+	return r.client.SMembers(ctx, key).Result()
+}
@@ -0,0 +1,105 @@
+package sessionstore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func members(t *testing.T, sets Sets, key string) []string {
+	t.Helper()
+	got, err := sets.Members(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Members failed: %v", err)
+	}
+	sort.Strings(got)
+	return got
+}
+
+func testSets(t *testing.T, sets Sets) {
+	ctx := context.Background()
+
+	if got := members(t, sets, "missing"); len(got) != 0 {
+		t.Errorf("Members of a missing set = %q, want none", got)
+	}
+
+	for _, member := range []string{"b", "a", "c", "a"} {
+		if err := sets.AddMember(ctx, "s", member, time.Hour); err != nil {
+			t.Fatalf("AddMember failed: %v", err)
+		}
+	}
+	if got, want := members(t, sets, "s"), []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Members = %q, want %q", got, want)
+	}
+
+	if err := sets.RemoveMembers(ctx, "s", "a", "missing"); err != nil {
+		t.Fatalf("RemoveMembers failed: %v", err)
+	}
+	if err := sets.RemoveMembers(ctx, "s"); err != nil {
+		t.Fatalf("RemoveMembers without members failed: %v", err)
+	}
+	if got, want := members(t, sets, "s"), []string{"b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Members after RemoveMembers = %q, want %q", got, want)
+	}
+
+	if err := sets.AddMember(ctx, "expired", "x", -time.Second); err != nil {
+		t.Fatalf("AddMember failed: %v", err)
+	}
+	if got := members(t, sets, "expired"); len(got) != 0 {
+		t.Errorf("Members of an expired set = %q, want none", got)
+	}
+
+	// Concurrent additions must not overwrite each other
+	var wg sync.WaitGroup
+	var want []string
+	for i := 0; i < 20; i++ {
+		member := fmt.Sprintf("m%02d", i)
+		want = append(want, member)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := sets.AddMember(ctx, "concurrent", member, time.Hour); err != nil {
+				t.Errorf("AddMember failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := members(t, sets, "concurrent"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Members after concurrent adds = %q, want %q", got, want)
+	}
+}
+
+func TestMemorySets(t *testing.T) {
+	m := NewMemory()
+	testSets(t, m)
+
+	m.GC(time.Now())
+	if _, ok := m.sets["expired"]; ok {
+		t.Error("GC kept an expired set")
+	}
+}
+
+func TestFileSets(t *testing.T) {
+	f, err := NewFile(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFile failed: %v", err)
+	}
+	testSets(t, f)
+
+	f.GC(time.Now())
+	if _, err := os.Stat(f.setDir("expired")); !os.IsNotExist(err) {
+		t.Errorf("GC kept an expired set: %v", err)
+	}
+	if got := members(t, f, "s"); len(got) != 2 {
+		t.Errorf("GC removed live members, %q left", got)
+	}
+	if tmp, _ := filepath.Glob(filepath.Join(f.dir, ".tmp-*")); len(tmp) != 0 {
+		t.Errorf("AddMember left temporary files %q", tmp)
+	}
+}
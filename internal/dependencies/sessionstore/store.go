@@ -0,0 +1,288 @@
+// Package sessionstore keeps sessions server-side, so the session cookie only
+// carries an opaque, signed session id. Sessions are stored in a Backend: in
+// memory, in files, or in a shared cache such as Redis.
+package sessionstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/cynx-io/cynx-core/src/logger"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+)
+
+const (
+	defaultTtl        = 7 * 24 * time.Hour
+	defaultGcInterval = 10 * time.Minute
+
+	// anonymousTtl is how long a session that is not logged in is kept, long
+	// enough for a pending login to complete
+	anonymousTtl = 10 * time.Minute
+
+	// touchAfter limits how often a sliding session is saved again on use
+	touchAfter = time.Minute
+)
+
+// LastSeenKey is the session value holding when the session was last used.
+const LastSeenKey = "last_seen"
+
+// ErrNotFound is returned by Backend.Load for a missing or expired session.
+var ErrNotFound = errors.New("session not found")
+
+// Backend stores encoded sessions by key until they expire.
+type Backend interface {
+	Load(ctx context.Context, key string) (data []byte, expiresAt time.Time, err error)
+	Save(ctx context.Context, key string, data []byte, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+}
+
+var backend Backend
+
+// Init creates the backend configured by session.store, if sessions are kept
+// server-side, and starts removing expired sessions from it.
+func Init() {
+	cfg := config.Config().Session
+
+	var err error
+	switch cfg.Store {
+	case "", "cookie":
+		return
+	case "memory":
+		backend = NewMemory()
+	case "file":
+		backend, err = NewFile(cfg.Dir)
+	case "redis":
+		var cache Cache
+		cache, err = NewRedis(cfg.RedisUrl)
+		backend = NewCacheBackend(cache)
+	default:
+		err = errors.New("unknown session store " + cfg.Store)
+	}
+	if err != nil {
+		panic("Failed to create session store: " + err.Error())
+	}
+
+	if gc, ok := backend.(interface{ GC(now time.Time) }); ok {
+		go collect(gc)
+	}
+	config.OnChange(reload)
+}
+
+func reload(prev, next *config.AppConfig) {
+	if prev.Session.Store != next.Session.Store || prev.Session.Dir != next.Session.Dir || prev.Session.RedisUrl != next.Session.RedisUrl {
+		logger.Error(context.Background(), "[SESSION] Changing the session store requires a restart, still using ", prev.Session.Store)
+	}
+}
+
+func collect(gc interface{ GC(now time.Time) }) {
+	for {
+		interval := defaultGcInterval
+		if seconds := config.Config().Session.GcInterval; seconds > 0 {
+			interval = time.Duration(seconds) * time.Second
+		}
+		time.Sleep(interval)
+		gc.GC(time.Now())
+	}
+}
+
+// Enabled reports whether sessions are kept server-side.
+func Enabled() bool {
+	return backend != nil
+}
+
+func ttl() time.Duration {
+	if seconds := config.Config().Session.Ttl; seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return defaultTtl
+}
+
+// lifetime is how long a session with values is kept from its last save:
+// session.ttl once it is logged in, anonymousTtl before.
+func lifetime(values map[interface{}]interface{}) time.Duration {
+	if values["authenticated"] != true {
+		return anonymousTtl
+	}
+	return ttl()
+}
+
+// Store is a sessions.Store that keeps session values in the backend under
+// prefix and the session id in a signed cookie.
+type Store struct {
+	Options *sessions.Options
+	codecs  []securecookie.Codec
+	prefix  string
+}
+
+var _ sessions.Store = (*Store)(nil)
+
+// NewStore returns a store for the sessions of one site. keyPairs sign the
+// session id cookie, as for sessions.NewCookieStore.
+func NewStore(prefix string, options sessions.Options, keyPairs ...[]byte) *Store {
+	codecs := securecookie.CodecsFromPairs(keyPairs...)
+	for _, codec := range codecs {
+		if sc, ok := codec.(*securecookie.SecureCookie); ok {
+			sc.MaxAge(options.MaxAge)
+		}
+	}
+	return &Store{Options: &options, codecs: codecs, prefix: prefix}
+}
+
+func (s *Store) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(s, name)
+}
+
+// New loads the session named by the request cookie. A missing, invalid or
+// expired session id yields a new, empty session rather than an error, so a
+// stale cookie never blocks logging in again.
+func (s *Store) New(r *http.Request, name string) (*sessions.Session, error) {
+	session := sessions.NewSession(s, name)
+	options := *s.Options
+	session.Options = &options
+	session.IsNew = true
+
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+	var id string
+	if err := securecookie.DecodeMulti(name, cookie.Value, &id, s.codecs...); err != nil {
+		return session, nil
+	}
+
+	ctx := r.Context()
+	data, expiresAt, err := backend.Load(ctx, s.prefix+id)
+	if errors.Is(err, ErrNotFound) {
+		return session, nil
+	}
+	if err != nil {
+		return session, err
+	}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&session.Values); err != nil {
+		return session, err
+	}
+	session.ID = id
+	session.IsNew = false
+
+	s.touch(ctx, session, expiresAt)
+	return session, nil
+}
+
+// touch records when the session was last used and, for sliding sessions,
+// pushes its expiry back. It saves at most once per touchAfter.
+func (s *Store) touch(ctx context.Context, session *sessions.Session, expiresAt time.Time) {
+	now := time.Now()
+	sliding := config.Config().Session.Sliding
+	full := lifetime(session.Values)
+	lastSeen, _ := session.Values[LastSeenKey].(time.Time)
+	if now.Sub(lastSeen) < touchAfter && !(sliding && expiresAt.Sub(now) < full-touchAfter) {
+		return
+	}
+
+	life := expiresAt.Sub(now)
+	if sliding {
+		life = full
+	}
+	session.Values[LastSeenKey] = now
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(session.Values); err != nil {
+		logger.Error(ctx, "[SESSION] Failed to encode session: ", err)
+		return
+	}
+	if err := backend.Save(ctx, s.prefix+session.ID, buf.Bytes(), life); err != nil {
+		logger.Error(ctx, "[SESSION] Failed to touch session: ", err)
+	}
+}
+
+// Key returns the backend key of a saved session.
+func (s *Store) Key(session *sessions.Session) string {
+	return s.prefix + session.ID
+}
+
+// Save stores the session values and sets the id cookie. A negative MaxAge
+// deletes the session. Sessions that are not logged in expire after
+// anonymousTtl; saving a logged in session gives it the full session.ttl.
+func (s *Store) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	ctx := r.Context()
+
+	if session.Options.MaxAge < 0 {
+		if session.ID != "" {
+			if err := backend.Delete(ctx, s.prefix+session.ID); err != nil {
+				return err
+			}
+		}
+		http.SetCookie(w, sessions.NewCookie(session.Name(), "", session.Options))
+		return nil
+	}
+
+	if session.ID == "" {
+		id, err := newID()
+		if err != nil {
+			return err
+		}
+		session.ID = id
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(session.Values); err != nil {
+		return err
+	}
+	if err := backend.Save(ctx, s.prefix+session.ID, buf.Bytes(), lifetime(session.Values)); err != nil {
+		return err
+	}
+
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, s.codecs...)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, sessions.NewCookie(session.Name(), encoded, session.Options))
+	return nil
+}
+
+// Renew moves the session to a new id and deletes the old one, so an id
+// known before login cannot be used after it. The session must be saved
+// afterwards.
+func (s *Store) Renew(r *http.Request, session *sessions.Session) error {
+	if session.ID != "" {
+		if err := backend.Delete(r.Context(), s.prefix+session.ID); err != nil {
+			return err
+		}
+	}
+	session.ID = ""
+	return nil
+}
+
+func newID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// encodeEntry prefixes data with its expiry, for backends that do not keep
+// one themselves.
+func encodeEntry(data []byte, expiresAt time.Time) []byte {
+	entry := make([]byte, 8+len(data))
+	binary.BigEndian.PutUint64(entry, uint64(expiresAt.UnixNano()))
+	copy(entry[8:], data)
+	return entry
+}
+
+func decodeEntry(entry []byte) ([]byte, time.Time, error) {
+	if len(entry) < 8 {
+		return nil, time.Time{}, errors.New("corrupt session entry")
+	}
+	expiresAt := time.Unix(0, int64(binary.BigEndian.Uint64(entry)))
+	return entry[8:], expiresAt, nil
+}
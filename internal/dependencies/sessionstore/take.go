@@ -0,0 +1,111 @@
+package sessionstore
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+// Taker is a Backend that can load and delete an entry in one step.
+type Taker interface {
+	Take(ctx context.Context, key string) (data []byte, err error)
+}
+
+var (
+	ephemeralOnce sync.Once
+	ephemeral     Backend
+)
+
+// Ephemeral returns the backend for short-lived values that replicas must
+// share like sessions, such as one-time codes. It is the session backend, or
+// process memory when sessions are kept in cookies.
+func Ephemeral() Backend {
+	if backend != nil {
+		return backend
+	}
+	ephemeralOnce.Do(func() {
+		memory := NewMemory()
+		go collect(memory)
+		ephemeral = memory
+	})
+	return ephemeral
+}
+
+// Take loads and deletes the entry under key, so only one of concurrent
+// callers gets it. Backends that are not a Taker fall back to a load
+// followed by a delete.
+func Take(ctx context.Context, b Backend, key string) ([]byte, error) {
+	if taker, ok := b.(Taker); ok {
+		return taker.Take(ctx, key)
+	}
+
+	data, _, err := b.Load(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return data, b.Delete(ctx, key)
+}
+
+func (m *Memory) Take(_ context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	entry, ok := m.entries[key]
+	delete(m.entries, key)
+	m.mu.Unlock()
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, ErrNotFound
+	}
+	return entry.data, nil
+}
+
+// Take moves the file out of the way before reading it, which only one
+// caller can do.
+func (f *File) Take(ctx context.Context, key string) ([]byte, error) {
+	tmp, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	taken := f.path(key) + ".taken-" + tmp
+	if err := os.Rename(f.path(key), taken); errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	defer os.Remove(taken)
+
+	entry, err := os.ReadFile(taken)
+	if err != nil {
+		return nil, err
+	}
+	data, expiresAt, err := decodeEntry(entry)
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(expiresAt) {
+		return nil, ErrNotFound
+	}
+	return data, nil
+}
+
+// Take uses the GetDel of the cache, if it has one.
+func (c *CacheBackend) Take(ctx context.Context, key string) ([]byte, error) {
+	getDel, ok := c.cache.(interface {
+		GetDel(ctx context.Context, key string) ([]byte, error)
+	})
+	if !ok {
+		data, _, err := c.Load(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		return data, c.Delete(ctx, key)
+	}
+
+	entry, err := getDel.GetDel(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	data, _, err := decodeEntry(entry)
+	return data, err
+}
@@ -1,12 +1,9 @@
 package ananke
 
 import (
-	"encoding/json"
 	pb "github.com/cynx-io/janus-gateway/api/proto/gen/ananke"
-	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/upstream"
 	"github.com/cynx-io/janus-gateway/internal/gateway/handlers"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 	"net/http"
 )
 
@@ -15,7 +12,7 @@ type PreorderHandler struct {
 }
 
 func NewPreorderHandler() *PreorderHandler {
-	conn, err := grpc.NewClient(config.Config.Ananke.Url, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := upstream.Dial("ananke")
 	if err != nil {
 		panic("Failed to connect to Ananke gRPC server: " + err.Error())
 	}
@@ -25,7 +22,7 @@ func NewPreorderHandler() *PreorderHandler {
 
 func (h *PreorderHandler) InitiatePreorder(w http.ResponseWriter, r *http.Request) {
 	var req pb.InitiatePreorderRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -36,7 +33,7 @@ func (h *PreorderHandler) InitiatePreorder(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -45,7 +42,7 @@ func (h *PreorderHandler) InitiatePreorder(w http.ResponseWriter, r *http.Reques
 
 func (h *PreorderHandler) GetLatestCompletedOrPendingPreorder(w http.ResponseWriter, r *http.Request) {
 	var req pb.GetLatestCompletedOrPendingPreorderRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -56,7 +53,7 @@ func (h *PreorderHandler) GetLatestCompletedOrPendingPreorder(w http.ResponseWri
 		return
 	}
 
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
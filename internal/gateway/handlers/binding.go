@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// BindValues sets fields of msg from string values such as query parameters
+// or path variables. Keys are proto field names (JSON names are accepted too)
+// with dots for nested messages, e.g. "page.limit"; repeated fields take every
+// value of their key. Keys matching no field are ignored, like unknown JSON
+// fields in DecodeRequest.
+func BindValues(msg protoreflect.Message, values map[string][]string) error {
+	for key, vals := range values {
+		if len(vals) == 0 {
+			continue
+		}
+		if err := bindField(msg, strings.Split(key, "."), vals); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func bindField(msg protoreflect.Message, path []string, vals []string) error {
+	fd := FindField(msg.Descriptor(), path[0])
+	if fd == nil {
+		return nil
+	}
+
+	if len(path) > 1 {
+		if fd.Message() == nil || fd.IsList() || fd.IsMap() {
+			return errors.New("not a message field")
+		}
+		return bindField(msg.Mutable(fd).Message(), path[1:], vals)
+	}
+
+	switch {
+	case fd.IsMap():
+		return errors.New("map fields cannot be bound")
+	case fd.IsList():
+		list := msg.Mutable(fd).List()
+		for _, s := range vals {
+			v, err := parseValue(fd, s, list.NewElement)
+			if err != nil {
+				return err
+			}
+			list.Append(v)
+		}
+	default:
+		v, err := parseValue(fd, vals[len(vals)-1], func() protoreflect.Value { return msg.NewField(fd) })
+		if err != nil {
+			return err
+		}
+		msg.Set(fd, v)
+	}
+	return nil
+}
+
+// FindField returns the field of md with the given proto or JSON name.
+func FindField(md protoreflect.MessageDescriptor, name string) protoreflect.FieldDescriptor {
+	fields := md.Fields()
+	if fd := fields.ByName(protoreflect.Name(name)); fd != nil {
+		return fd
+	}
+	return fields.ByJSONName(name)
+}
+
+func parseValue(fd protoreflect.FieldDescriptor, s string, newValue func() protoreflect.Value) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(s)
+		return protoreflect.ValueOfBool(b), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		i, err := strconv.ParseInt(s, 10, 32)
+		return protoreflect.ValueOfInt32(int32(i)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		i, err := strconv.ParseInt(s, 10, 64)
+		return protoreflect.ValueOfInt64(i), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		u, err := strconv.ParseUint(s, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(u)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		u, err := strconv.ParseUint(s, 10, 64)
+		return protoreflect.ValueOfUint64(u), err
+	case protoreflect.FloatKind:
+		f, err := strconv.ParseFloat(s, 32)
+		return protoreflect.ValueOfFloat32(float32(f)), err
+	case protoreflect.DoubleKind:
+		f, err := strconv.ParseFloat(s, 64)
+		return protoreflect.ValueOfFloat64(f), err
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(s), nil
+	case protoreflect.BytesKind:
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			b, err = base64.URLEncoding.DecodeString(s)
+		}
+		return protoreflect.ValueOfBytes(b), err
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(s)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+		i, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return protoreflect.Value{}, errors.New("unknown enum value")
+		}
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(i)), nil
+	default:
+		// Messages bind through their protojson string form, which covers
+		// well-known types such as Timestamp, Duration and the wrappers.
+		v := newValue()
+		err := protojson.Unmarshal([]byte(strconv.Quote(s)), v.Message().Interface())
+		return v, err
+	}
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// bindingMessage describes test.Request, with a field of each kind that
+// BindValues handles.
+func bindingMessage(t *testing.T) protoreflect.MessageDescriptor {
+	t.Helper()
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string, repeated bool) *descriptorpb.FieldDescriptorProto {
+		label := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+		if repeated {
+			label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+		}
+		f := &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String(name),
+			Number: proto.Int32(number),
+			Label:  label.Enum(),
+			Type:   typ.Enum(),
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+
+	file := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("test/binding.proto"),
+		Package:    proto.String("test"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/timestamp.proto"},
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Status"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("STATUS_UNKNOWN"), Number: proto.Int32(0)},
+				{Name: proto.String("STATUS_ACTIVE"), Number: proto.Int32(1)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Page"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("page_limit", 1, descriptorpb.FieldDescriptorProto_TYPE_INT32, "", false),
+				},
+			},
+			{
+				Name: proto.String("Request"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("name", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, "", false),
+					field("id", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32, "", false),
+					field("big", 3, descriptorpb.FieldDescriptorProto_TYPE_UINT64, "", false),
+					field("active", 4, descriptorpb.FieldDescriptorProto_TYPE_BOOL, "", false),
+					field("score", 5, descriptorpb.FieldDescriptorProto_TYPE_DOUBLE, "", false),
+					field("data", 6, descriptorpb.FieldDescriptorProto_TYPE_BYTES, "", false),
+					field("status", 7, descriptorpb.FieldDescriptorProto_TYPE_ENUM, ".test.Status", false),
+					field("tags", 8, descriptorpb.FieldDescriptorProto_TYPE_STRING, "", true),
+					field("page", 9, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".test.Page", false),
+					field("pages", 10, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".test.Page", true),
+					field("since", 11, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".google.protobuf.Timestamp", false),
+				},
+			},
+		},
+	}
+
+	fd, err := protodesc.NewFile(file, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatalf("Failed to build descriptor: %v", err)
+	}
+	return fd.Messages().ByName("Request")
+}
+
+func TestBindValues(t *testing.T) {
+	md := bindingMessage(t)
+	since := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		values map[string][]string
+		check  func(t *testing.T, msg protoreflect.Message)
+	}{
+		{
+			name:   "string",
+			values: map[string][]string{"name": {"ada"}},
+			check:  expectField("name", "ada"),
+		},
+		{
+			name:   "last value wins for singular fields",
+			values: map[string][]string{"id": {"1", "42"}},
+			check:  expectField("id", int32(42)),
+		},
+		{
+			name:   "uint64",
+			values: map[string][]string{"big": {"18446744073709551615"}},
+			check:  expectField("big", uint64(18446744073709551615)),
+		},
+		{
+			name:   "bool",
+			values: map[string][]string{"active": {"true"}},
+			check:  expectField("active", true),
+		},
+		{
+			name:   "double",
+			values: map[string][]string{"score": {"2.5"}},
+			check:  expectField("score", 2.5),
+		},
+		{
+			name:   "standard base64 bytes",
+			values: map[string][]string{"data": {"aGk/"}},
+			check:  expectBytes("data", "hi?"),
+		},
+		{
+			name:   "URL-safe base64 bytes",
+			values: map[string][]string{"data": {"aGk_"}},
+			check:  expectBytes("data", "hi?"),
+		},
+		{
+			name:   "enum by name",
+			values: map[string][]string{"status": {"STATUS_ACTIVE"}},
+			check:  expectField("status", protoreflect.EnumNumber(1)),
+		},
+		{
+			name:   "enum by number",
+			values: map[string][]string{"status": {"1"}},
+			check:  expectField("status", protoreflect.EnumNumber(1)),
+		},
+		{
+			name:   "repeated takes every value",
+			values: map[string][]string{"tags": {"a", "b"}},
+			check: func(t *testing.T, msg protoreflect.Message) {
+				list := msg.Get(md.Fields().ByName("tags")).List()
+				if list.Len() != 2 || list.Get(0).String() != "a" || list.Get(1).String() != "b" {
+					t.Errorf("tags = %v, want [a b]", list)
+				}
+			},
+		},
+		{
+			name:   "nested field by proto name",
+			values: map[string][]string{"page.page_limit": {"10"}},
+			check:  expectNested("page", "page_limit", int32(10)),
+		},
+		{
+			name:   "nested field by JSON name",
+			values: map[string][]string{"page.pageLimit": {"10"}},
+			check:  expectNested("page", "page_limit", int32(10)),
+		},
+		{
+			name:   "timestamp from its JSON form",
+			values: map[string][]string{"since": {since.Format(time.RFC3339)}},
+			check: func(t *testing.T, msg protoreflect.Message) {
+				ts := msg.Get(md.Fields().ByName("since")).Message()
+				seconds := ts.Get(ts.Descriptor().Fields().ByName("seconds")).Int()
+				if seconds != since.Unix() {
+					t.Errorf("since.seconds = %d, want %d", seconds, since.Unix())
+				}
+			},
+		},
+		{
+			name:   "unknown keys are ignored",
+			values: map[string][]string{"missing": {"x"}, "page.missing": {"x"}, "name": {}},
+			check: func(t *testing.T, msg protoreflect.Message) {
+				if msg.Has(md.Fields().ByName("name")) {
+					t.Error("name was set from an empty value list")
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := dynamicpb.NewMessage(md)
+			if err := BindValues(msg, tt.values); err != nil {
+				t.Fatalf("BindValues failed: %v", err)
+			}
+			tt.check(t, msg)
+		})
+	}
+}
+
+func TestBindValuesErrors(t *testing.T) {
+	md := bindingMessage(t)
+	tests := []struct {
+		name   string
+		values map[string][]string
+	}{
+		{"int out of range", map[string][]string{"id": {"2147483648"}}},
+		{"not a number", map[string][]string{"id": {"ten"}}},
+		{"negative uint", map[string][]string{"big": {"-1"}}},
+		{"not a bool", map[string][]string{"active": {"yes please"}}},
+		{"not base64", map[string][]string{"data": {"!!"}}},
+		{"unknown enum name", map[string][]string{"status": {"STATUS_GONE"}}},
+		{"bad timestamp", map[string][]string{"since": {"yesterday"}}},
+		{"path through a scalar", map[string][]string{"name.first": {"ada"}}},
+		{"path through a repeated message", map[string][]string{"pages.page_limit": {"1"}}},
+		{"bad repeated element", map[string][]string{"pages": {"x"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := BindValues(dynamicpb.NewMessage(md), tt.values); err == nil {
+				t.Errorf("BindValues(%v) succeeded, want an error", tt.values)
+			}
+		})
+	}
+}
+
+func expectField(name string, want any) func(*testing.T, protoreflect.Message) {
+	return func(t *testing.T, msg protoreflect.Message) {
+		got := msg.Get(msg.Descriptor().Fields().ByName(protoreflect.Name(name))).Interface()
+		if got != want {
+			t.Errorf("%s = %v (%T), want %v (%T)", name, got, got, want, want)
+		}
+	}
+}
+
+func expectBytes(name string, want string) func(*testing.T, protoreflect.Message) {
+	return func(t *testing.T, msg protoreflect.Message) {
+		got := msg.Get(msg.Descriptor().Fields().ByName(protoreflect.Name(name))).Bytes()
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func expectNested(name string, field string, want any) func(*testing.T, protoreflect.Message) {
+	return func(t *testing.T, msg protoreflect.Message) {
+		nested := msg.Get(msg.Descriptor().Fields().ByName(protoreflect.Name(name))).Message()
+		expectField(field, want)(t, nested)
+	}
+}
@@ -2,25 +2,169 @@ package handlers
 
 import (
 	"context"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	pbCore "github.com/cynx-io/cynx-core/proto/gen"
+	contextcore "github.com/cynx-io/cynx-core/src/context"
 	"github.com/cynx-io/cynx-core/src/logger"
+	"github.com/cynx-io/janus-gateway/internal/constant"
+	"github.com/gorilla/mux"
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
-	"net/http"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
-func HandleResponse(w http.ResponseWriter, resp proto.Message) error {
-	marshaler := protojson.MarshalOptions{
-		EmitUnpopulated: true,
-		UseProtoNames:   true,
+// IsProtoContentType reports whether the given Content-Type or Accept media
+// type is one of the binary protobuf types.
+func IsProtoContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == constant.ContentTypeProto || mediaType == constant.ContentTypeProtobuf
+}
+
+// negotiateProto returns the binary protobuf media type the client asked for
+// in its Accept header, or an empty string if it wants JSON.
+func negotiateProto(r *http.Request) string {
+	if r == nil {
+		return ""
+	}
+	for _, accept := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil || params["q"] == "0" {
+				continue
+			}
+			if mediaType == constant.ContentTypeProto || mediaType == constant.ContentTypeProtobuf {
+				return mediaType
+			}
+		}
+	}
+	return ""
+}
+
+// queryHandler is a handler wrapped in AllowGet, which BindsQuery finds on
+// the matched route.
+type queryHandler http.HandlerFunc
+
+func (h queryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h(w, r)
+}
+
+// AllowGet lets a read-only RPC be called with GET, in which case the query
+// parameters are bound onto the request message instead of reading a body.
+// Register the result with Handle, not HandleFunc, which would hide it.
+func AllowGet(next http.HandlerFunc) http.Handler {
+	return queryHandler(next)
+}
+
+// BindsQuery reports whether the request message of r comes from its query
+// parameters rather than its body, which is for GET calls to routes wrapped
+// in AllowGet. Middleware and handlers must both decide with it.
+func BindsQuery(r *http.Request) bool {
+	if r.Method != http.MethodGet {
+		return false
+	}
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return false
+	}
+	_, ok := route.GetHandler().(queryHandler)
+	return ok
+}
+
+// decoded is a request message decoded by a middleware for the handler.
+type decoded struct {
+	path string
+	msg  proto.Message
+}
+
+// Decode reads the request message of r into req: the query parameters if
+// BindsQuery, otherwise the body, as binary protobuf when the Content-Type
+// asks for it and as protojson otherwise.
+func Decode(r *http.Request, req proto.Message) error {
+	if BindsQuery(r) {
+		return BindValues(req.ProtoReflect(), r.URL.Query())
 	}
 
-	data, err := marshaler.Marshal(resp)
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	if IsProtoContentType(r.Header.Get("Content-Type")) {
+		return proto.Unmarshal(body, req)
+	}
+	return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(body, req)
+}
+
+// WithDecoded hands msg, decoded from r with Decode, to DecodeRequest, so a
+// middleware checking the request and the handler sending it upstream see the
+// same message.
+func WithDecoded(r *http.Request, msg proto.Message) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), constant.ContextKeyDecodedRequest, decoded{path: r.URL.Path, msg: msg}))
+}
+
+// DecodeRequest decodes the request message of r into req with Decode, or
+// takes the one a middleware already decoded. It then injects the
+// BaseRequest placed in the context by BaseRequestHandler.
+func DecodeRequest(r *http.Request, req proto.Message) error {
+	// Internal requests inherit the context, so the path must match too
+	if d, ok := r.Context().Value(constant.ContextKeyDecodedRequest).(decoded); ok && d.path == r.URL.Path &&
+		d.msg.ProtoReflect().Descriptor().FullName() == req.ProtoReflect().Descriptor().FullName() {
+		proto.Reset(req)
+		proto.Merge(req, d.msg)
+	} else if err := Decode(r, req); err != nil {
+		return err
+	}
+
+	SetBaseRequest(req, contextcore.GetBaseRequest(r.Context()))
+	return nil
+}
+
+// SetBaseRequest sets the "base" field of msg to a copy of base, if msg has
+// a core.BaseRequest field with that name.
+func SetBaseRequest(msg proto.Message, base *pbCore.BaseRequest) {
+	if msg == nil || base == nil {
+		return
+	}
+
+	m := msg.ProtoReflect()
+	fd := m.Descriptor().Fields().ByName("base")
+	if fd == nil || fd.Message() == nil || fd.Message().FullName() != base.ProtoReflect().Descriptor().FullName() {
+		return
+	}
+
+	m.Set(fd, protoreflect.ValueOfMessage(proto.Clone(base).ProtoReflect()))
+}
+
+func HandleResponse(w http.ResponseWriter, r *http.Request, resp proto.Message) error {
+	var (
+		data        []byte
+		err         error
+		contentType = negotiateProto(r)
+	)
+
+	if contentType != "" {
+		data, err = proto.Marshal(resp)
+	} else {
+		contentType = constant.ContentTypeJSON
+		marshaler := protojson.MarshalOptions{
+			EmitUnpopulated: true,
+			UseProtoNames:   true,
+		}
+		data, err = marshaler.Marshal(resp)
+	}
 	if err != nil {
 		logger.Error(context.Background(), "Failed to marshal response: ", err)
 		return err
 	}
 
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Add("Vary", "Accept")
 	w.WriteHeader(http.StatusOK)
 	_, err = w.Write(data)
 	if err != nil {
@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/cynx-io/janus-gateway/internal/constant"
+)
+
+// NewInternalRequest builds a JSON POST to path on behalf of r, keeping its
+// headers, host and client address so the gateway router runs the same
+// CORS, auth and base request middleware as for a direct call.
+func NewInternalRequest(r *http.Request, path string, body []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodPost, path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header = r.Header.Clone()
+	req.Header.Set("Content-Type", constant.ContentTypeJSON)
+	req.Header.Del("Content-Length")
+	req.Host = r.Host
+	req.RemoteAddr = r.RemoteAddr
+	return req, nil
+}
@@ -0,0 +1,191 @@
+package janus
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cynx-io/cynx-core/src/logger"
+	"github.com/cynx-io/janus-gateway/internal/apikey"
+	"github.com/cynx-io/janus-gateway/internal/constant"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+	"github.com/cynx-io/janus-gateway/internal/gateway/handlers"
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultBatchMaxItems       = 20
+	defaultBatchMaxConcurrency = 4
+)
+
+// batchMethodPattern matches "package.Service/Method" so a batch item can only
+// target an RPC route and never /batch itself or the /auth0 endpoints.
+var batchMethodPattern = regexp.MustCompile(`^[A-Za-z0-9_]+(\.[A-Za-z0-9_]+)+/[A-Za-z0-9_]+$`)
+
+type BatchHandler struct {
+	router http.Handler
+}
+
+type BatchItem struct {
+	Method string          `json:"method"`
+	Body   json.RawMessage `json:"body"`
+}
+
+type BatchItemResult struct {
+	Method string          `json:"method"`
+	Body   json.RawMessage `json:"body"`
+	Status int             `json:"status"`
+}
+
+// NewBatchHandler returns a handler that dispatches every batch item through
+// router, so each item runs the same CORS, auth, base request and logging
+// middleware as a standalone call to its route.
+func NewBatchHandler(router http.Handler) *BatchHandler {
+	return &BatchHandler{router: router}
+}
+
+func (h *BatchHandler) InjectRoutes(router *mux.Router) {
+	router.HandleFunc("/batch", h.Batch).Methods(http.MethodPost, http.MethodOptions)
+}
+
+func (h *BatchHandler) Batch(w http.ResponseWriter, r *http.Request) {
+	var items []BatchItem
+	if err := json.NewDecoder(r.Body).Decode(&items); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	maxItems := config.Config().Batch.MaxItems
+	if maxItems <= 0 {
+		maxItems = defaultBatchMaxItems
+	}
+	if len(items) == 0 || len(items) > maxItems {
+		http.Error(w, "Batch must contain between 1 and "+strconv.Itoa(maxItems)+" items", http.StatusBadRequest)
+		return
+	}
+
+	// An API key caller pays for every item, the batch call itself counted
+	// as the first one
+	if key, ok := apikey.FromContext(r.Context()); ok {
+		allowed, remaining, reset := apikey.AllowN(key, len(items)-1)
+		apikey.SetRateLimitHeaders(w.Header(), key, remaining, reset)
+		if !allowed {
+			logger.Info(r.Context(), "[API KEY] Rate limit exceeded for key ", key.Id, " by a batch of ", len(items))
+			w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(reset).Seconds())+1))
+			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+			return
+		}
+	}
+
+	maxConcurrency := config.Config().Batch.MaxConcurrency
+	if maxConcurrency <= 0 {
+		maxConcurrency = defaultBatchMaxConcurrency
+	}
+
+	results := make([]BatchItemResult, len(items))
+	recorders := make([]*batchRecorder, len(items))
+	sem := make(chan struct{}, maxConcurrency)
+	var wg sync.WaitGroup
+
+	for i, item := range items {
+		if !batchMethodPattern.MatchString(item.Method) {
+			results[i] = batchError(item.Method, http.StatusBadRequest, "Invalid method")
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, item BatchItem) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			recorders[i] = h.dispatch(r, item)
+			results[i] = recorders[i].result(item.Method)
+		}(i, item)
+	}
+	wg.Wait()
+
+	// Forward cookies set by the items, e.g. a refreshed session
+	for _, rec := range recorders {
+		if rec == nil {
+			continue
+		}
+		for _, cookie := range rec.header.Values("Set-Cookie") {
+			w.Header().Add("Set-Cookie", cookie)
+		}
+	}
+
+	w.Header().Set("Content-Type", constant.ContentTypeJSON)
+	if err := json.NewEncoder(w).Encode(results); err != nil {
+		logger.Error(r.Context(), "Failed to encode batch response: ", err)
+	}
+}
+
+func (h *BatchHandler) dispatch(r *http.Request, item BatchItem) *batchRecorder {
+	body := []byte(item.Body)
+	if len(body) == 0 || string(body) == "null" {
+		body = []byte("{}")
+	}
+
+	// Run the item as the caller: same origin, host, cookies and client address
+	req, err := handlers.NewInternalRequest(r, "/"+item.Method, body)
+	rec := newBatchRecorder()
+	if err != nil {
+		http.Error(rec, "Invalid request", http.StatusBadRequest)
+		return rec
+	}
+	req.Header.Set("Accept", constant.ContentTypeJSON)
+
+	h.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// batchRecorder is a minimal http.ResponseWriter that buffers one item's response.
+type batchRecorder struct {
+	header http.Header
+	body   bytes.Buffer
+	status int
+}
+
+func newBatchRecorder() *batchRecorder {
+	return &batchRecorder{header: make(http.Header)}
+}
+
+func (rec *batchRecorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *batchRecorder) WriteHeader(code int) {
+	if rec.status == 0 {
+		rec.status = code
+	}
+}
+
+func (rec *batchRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	return rec.body.Write(b)
+}
+
+func (rec *batchRecorder) result(method string) BatchItemResult {
+	status := rec.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	body := bytes.TrimSpace(rec.body.Bytes())
+	if len(body) == 0 || !json.Valid(body) {
+		return batchError(method, status, string(body))
+	}
+	return BatchItemResult{Method: method, Status: status, Body: body}
+}
+
+func batchError(method string, status int, message string) BatchItemResult {
+	body, _ := json.Marshal(message)
+	return BatchItemResult{Method: method, Status: status, Body: body}
+}
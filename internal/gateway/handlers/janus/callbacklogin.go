@@ -2,128 +2,120 @@ package janus
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
-	gen "github.com/cynx-io/cynx-core/proto/gen"
-	"github.com/cynx-io/cynx-core/src/logger"
-	proto "github.com/cynx-io/janus-gateway/api/proto/gen/hermes"
+	"errors"
+	"github.com/cynx-io/janus-gateway/internal/bearer"
 	"github.com/cynx-io/janus-gateway/internal/dependencies/auth0"
-	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
 	"github.com/cynx-io/janus-gateway/internal/helper"
 	"github.com/cynx-io/janus-gateway/internal/session"
+	"golang.org/x/oauth2"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 func (h *GatewayHandler) Auth0CallbackLogin(w http.ResponseWriter, r *http.Request) {
-	sessionState, err := session.GetState(r)
+	login, err := session.FinishLogin(w, r, r.URL.Query().Get("state"))
+	if errors.Is(err, session.ErrUnknownLogin) {
+		http.Error(w, "Invalid state parameter", http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Failed to get session", http.StatusInternalServerError)
 		return
 	}
 
-	state := r.URL.Query().Get("state")
-	if state != sessionState {
-		http.Error(w, "Invalid state parameter", http.StatusBadRequest)
+	if login.Silent {
+		h.silentCallback(w, r, login)
+		return
+	}
+
+	userSession, failure := h.completeLogin(w, r, login)
+	if failure != nil {
+		http.Error(w, failure.message, failure.status)
+		return
+	}
+
+	redirectURL := login.RedirectURL
+	if redirectURL != "" {
+		http.Redirect(w, r, redirectURL, http.StatusTemporaryRedirect)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	response := map[string]interface{}{
+		"success": true,
+		"user":    userSession,
+		"message": "Login successful",
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// loginFailure is why a callback could not log in, with the status and
+// message to respond with.
+type loginFailure struct {
+	status  int
+	message string
+}
+
+// completeLogin redeems the authorization code Auth0 sent back for login and
+// logs the session in.
+func (h *GatewayHandler) completeLogin(w http.ResponseWriter, r *http.Request, login *session.Login) (*session.UserSession, *loginFailure) {
 	code := r.URL.Query().Get("code")
 	if code == "" {
-		http.Error(w, "Code not found", http.StatusBadRequest)
-		return
+		return nil, &loginFailure{http.StatusBadRequest, "Code not found"}
 	}
 
 	siteKey, err := helper.GetSiteKey(r)
 	if err != nil {
-		http.Error(w, "Failed to get site key: "+err.Error(), http.StatusInternalServerError)
-		return
+		return nil, &loginFailure{http.StatusInternalServerError, "Failed to get site key: " + err.Error()}
 	}
-	token, err := auth0.Oauth2[siteKey].Exchange(context.Background(), code)
+	token, err := auth0.Oauth2(siteKey).Exchange(context.Background(), code, oauth2.VerifierOption(login.Verifier))
 	if err != nil {
-		http.Error(w, "Failed to exchange code: "+err.Error(), http.StatusInternalServerError)
-		return
+		return nil, &loginFailure{http.StatusInternalServerError, "Failed to exchange code: " + err.Error()}
 	}
 
 	rawIDToken, ok := token.Extra("id_token").(string)
 	if !ok {
-		http.Error(w, "No id_token field in token", http.StatusInternalServerError)
-		return
+		return nil, &loginFailure{http.StatusInternalServerError, "No id_token field in token"}
 	}
 
-	idToken, err := auth0.Verifier[siteKey].Verify(context.Background(), rawIDToken)
+	idToken, err := auth0.Verifier(siteKey).Verify(context.Background(), rawIDToken)
 	if err != nil {
-		http.Error(w, "Failed to verify ID Token: "+err.Error(), http.StatusInternalServerError)
-		return
+		return nil, &loginFailure{http.StatusInternalServerError, "Failed to verify ID Token: " + err.Error()}
 	}
 
-	var claims map[string]interface{}
-	if err := idToken.Claims(&claims); err != nil {
-		http.Error(w, "Failed to get claims: "+err.Error(), http.StatusInternalServerError)
-		return
+	// The ID token must come from the login attempt this browser started
+	if subtle.ConstantTimeCompare([]byte(idToken.Nonce), []byte(login.Nonce)) != 1 {
+		return nil, &loginFailure{http.StatusBadRequest, "Invalid nonce"}
 	}
 
-	req := proto.UpsertUserRequest{
-		Base: &gen.BaseRequest{},
-	}
-	req.Auth0Id = claims["sub"].(string)
-	req.Email = claims["email"].(string)
-	name := claims["name"].(string)
-	req.Name = &name
-	isActive := true
-	req.IsActive = &isActive
-
-	userResp, err := h.userClient.UpsertUser(r.Context(), &req)
-
+	user, err := bearer.UpsertUser(r.Context(), idToken.Subject, idToken)
 	if err != nil {
-		http.Error(w, "Failed to upsert user: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	if userResp == nil || userResp.User == nil {
-		http.Error(w, "User not found in response", http.StatusInternalServerError)
-		return
+		return nil, &loginFailure{http.StatusInternalServerError, "Failed to upsert user: " + err.Error()}
 	}
 
 	userSession := &session.UserSession{
-		UserID:        string(userResp.User.Id),
-		Email:         userResp.User.Email,
-		Name:          userResp.User.Name,
+		UserID:        strconv.Itoa(int(user.UserId)),
+		Email:         user.Email,
+		Name:          user.Name,
+		Roles:         helper.MergeRoles(auth0.Roles(idToken), user.Roles),
+		Subject:       idToken.Subject,
+		Auth0Roles:    auth0.Roles(idToken),
+		CheckedAt:     time.Now(),
 		Authenticated: true,
 		AccessToken:   token.AccessToken,
 		RefreshToken:  token.RefreshToken,
 		ExpiresAt:     token.Expiry,
 	}
 
-	err = session.SetSession(w, r, userSession)
+	err = session.LogIn(w, r, userSession)
 	if err != nil {
-		http.Error(w, "Failed to save session: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	redirectURL, err := session.GetRedirectURL(r)
-	if err != nil || redirectURL == "" {
-		siteKey, _ := helper.GetSiteKey(r)
-		redirectURL = config.Config.Sites.Get(siteKey).Auth0.FrontendUrl
-	}
-
-	err = session.ClearRedirectURL(w, r)
-	if err != nil {
-		logger.Error(r.Context(), "Failed to clear redirect URL: ", err)
-		return
-	}
-
-	if redirectURL != "" {
-		http.Redirect(w, r, redirectURL, http.StatusTemporaryRedirect)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	response := map[string]interface{}{
-		"success": true,
-		"user":    userSession,
-		"message": "Login successful",
-	}
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
-		return
+		return nil, &loginFailure{http.StatusInternalServerError, "Failed to save session: " + err.Error()}
 	}
+	return userSession, nil
 }
@@ -0,0 +1,50 @@
+package janus
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+
+	"github.com/cynx-io/cynx-core/src/logger"
+	"github.com/cynx-io/janus-gateway/internal/constant"
+	"github.com/cynx-io/janus-gateway/internal/gateway/routes"
+	"github.com/gorilla/mux"
+)
+
+type DebugHandler struct {
+	router *mux.Router
+}
+
+// NewDebugHandler returns a handler exposing introspection of router.
+func NewDebugHandler(router *mux.Router) *DebugHandler {
+	return &DebugHandler{router: router}
+}
+
+// InjectRoutes adds the debug routes to adminRouter, whose AdminMiddleware
+// serves them to admins only.
+func (h *DebugHandler) InjectRoutes(adminRouter *mux.Router) {
+	debug := adminRouter.PathPrefix("/debug").Subrouter()
+
+	debug.HandleFunc("/routes", h.Routes).Methods("GET")
+	debug.HandleFunc("/vars", h.Vars).Methods("GET")
+}
+
+// Vars serves the expvar metrics, such as config_reloads.
+func (h *DebugHandler) Vars(w http.ResponseWriter, r *http.Request) {
+	expvar.Handler().ServeHTTP(w, r)
+}
+
+func (h *DebugHandler) Routes(w http.ResponseWriter, r *http.Request) {
+	list, err := routes.List(h.router)
+	if err != nil {
+		logger.Error(r.Context(), "Failed to list routes: ", err)
+		http.Error(w, "Failed to list routes", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", constant.ContentTypeJSON)
+	if err := json.NewEncoder(w).Encode(routes.Describe(list)); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
@@ -1,40 +1,89 @@
 package janus
 
 import (
-	"github.com/cynx-io/cynx-core/src/helper/random"
+	"fmt"
+	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/cynx-io/janus-gateway/internal/dependencies/auth0"
 	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
 	"github.com/cynx-io/janus-gateway/internal/helper"
 	"github.com/cynx-io/janus-gateway/internal/session"
+	"golang.org/x/oauth2"
 	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
 )
 
 func (h *GatewayHandler) Auth0Login(w http.ResponseWriter, r *http.Request) {
-	state := random.RandomAlphanumerics(32, 32)
-
 	siteKey, err := helper.GetSiteKey(r)
 	if err != nil {
 		http.Error(w, "Failed to get site key: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	redirectURL := r.URL.Query().Get("redirect_url")
-	if redirectURL == "" {
-		redirectURL = config.Config.Sites.Get(siteKey).Auth0.FrontendUrl
+	redirectURL, err := config.Config().Sites.Get(siteKey).RedirectTarget(r.URL.Query().Get("redirect_url"))
+	if err != nil {
+		http.Error(w, "Invalid redirect_url: "+err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	err = session.SetState(w, r, state)
+	options, err := loginOptions(config.Config().Sites.Get(siteKey), r.URL.Query())
 	if err != nil {
-		http.Error(w, "Failed to save session: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Invalid login option: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	err = session.SetRedirectURL(w, r, redirectURL)
+	state, login, err := session.StartLogin(w, r, session.Login{RedirectURL: redirectURL})
 	if err != nil {
-		http.Error(w, "Failed to save redirect URL: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Failed to save session: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	url := auth0.Oauth2[siteKey].AuthCodeURL(state)
-	http.Redirect(w, r, url, http.StatusTemporaryRedirect)
+	options = append(options, oauth2.S256ChallengeOption(login.Verifier), oidc.Nonce(login.Nonce))
+	authURL := auth0.Oauth2(siteKey).AuthCodeURL(state, options...)
+	http.Redirect(w, r, authURL, http.StatusTemporaryRedirect)
+}
+
+var (
+	localesPattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*( [A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*)*$`)
+	screenHints    = []string{"login", "signup"}
+	prompts        = []string{"login", "consent", "select_account"}
+)
+
+const maxLoginHint = 256
+
+// loginOptions returns the Auth0 authorize parameters for the login options
+// in query, which may only be connection, organization, screen_hint,
+// ui_locales, login_hint and prompt. Connections and organizations must be
+// configured for the site.
+func loginOptions(site config.SiteConfig, query url.Values) ([]oauth2.AuthCodeOption, error) {
+	in := func(list []string) func(string) bool {
+		return func(value string) bool {
+			return slices.Contains(list, value)
+		}
+	}
+	checks := []struct {
+		name    string
+		allowed func(value string) bool
+	}{
+		{"connection", in(site.Auth0.Connections)},
+		{"organization", in(site.Auth0.Organizations)},
+		{"screen_hint", in(screenHints)},
+		{"prompt", in(prompts)},
+		{"ui_locales", localesPattern.MatchString},
+		{"login_hint", func(value string) bool { return len(value) <= maxLoginHint }},
+	}
+
+	var options []oauth2.AuthCodeOption
+	for _, check := range checks {
+		value := query.Get(check.name)
+		if value == "" {
+			continue
+		}
+		if !check.allowed(value) {
+			return nil, fmt.Errorf("%s %q is not allowed", check.name, value)
+		}
+		options = append(options, oauth2.SetAuthURLParam(check.name, value))
+	}
+	return options, nil
 }
@@ -2,11 +2,11 @@ package janus
 
 import (
 	"encoding/json"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/auth0"
 	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
 	"github.com/cynx-io/janus-gateway/internal/helper"
 	"github.com/cynx-io/janus-gateway/internal/session"
 	"net/http"
-	"net/url"
 )
 
 func (h *GatewayHandler) Auth0Logout(w http.ResponseWriter, r *http.Request) {
@@ -17,6 +17,14 @@ func (h *GatewayHandler) Auth0Logout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Validate before clearing, so a bad returnTo does not log out
+	site := config.Config().Sites.Get(siteKey)
+	returnTo, err := site.RedirectTarget(r.URL.Query().Get("returnTo"))
+	if err != nil {
+		http.Error(w, "Invalid returnTo: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Clear the session
 	err = session.ClearSession(w, r)
 	if err != nil {
@@ -25,17 +33,11 @@ func (h *GatewayHandler) Auth0Logout(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Build Auth0 logout URL
-	logoutURL := "https://" + config.Config.Auth0.Domain + "v2/logout"
-	params := url.Values{}
-	params.Add("client_id", config.Config.Sites.Get(siteKey).Auth0.ClientId)
-	if config.Config.Sites.Get(siteKey).Auth0.FrontendUrl != "" {
-		params.Add("returnTo", config.Config.Sites.Get(siteKey).Auth0.FrontendUrl)
-	}
+	logoutURL := auth0.LogoutURL(site.Auth0.ClientId, returnTo)
 
 	// Check if client wants to redirect to Auth0 logout
 	if r.URL.Query().Get("type") == "full" || r.URL.Query().Get("redirect") == "true" {
-		fullLogoutURL := logoutURL + "?" + params.Encode()
-		http.Redirect(w, r, fullLogoutURL, http.StatusTemporaryRedirect)
+		http.Redirect(w, r, logoutURL, http.StatusTemporaryRedirect)
 		return
 	}
 
@@ -44,7 +46,7 @@ func (h *GatewayHandler) Auth0Logout(w http.ResponseWriter, r *http.Request) {
 	response := map[string]interface{}{
 		"success":    true,
 		"message":    "Logged out successfully",
-		"logout_url": logoutURL + "?" + params.Encode(),
+		"logout_url": logoutURL,
 	}
 
 	if err := json.NewEncoder(w).Encode(response); err != nil {
@@ -0,0 +1,118 @@
+package janus
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/cynx-io/cynx-core/src/logger"
+	"github.com/cynx-io/janus-gateway/internal/constant"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+	"github.com/cynx-io/janus-gateway/internal/gateway/openapi"
+	"github.com/cynx-io/janus-gateway/internal/gateway/rest"
+	"github.com/cynx-io/janus-gateway/internal/gateway/routes"
+	"github.com/cynx-io/janus-gateway/internal/helper"
+	"github.com/gorilla/mux"
+)
+
+// docsFiles is the API reference page and the Swagger UI it renders with,
+// vendored under docs/swagger-ui and served under /docs so the page does not
+// load anything from a CDN.
+//
+//go:embed docs
+var docsFiles embed.FS
+
+type OpenAPIHandler struct {
+	router *mux.Router
+	doc    atomic.Pointer[[]byte]
+}
+
+// NewOpenAPIHandler returns a handler documenting the routes registered on
+// router and their REST bindings. The document is built on first use, once
+// all routes are injected, and rebuilt on config reloads since it lists the
+// sites and REST mappings.
+func NewOpenAPIHandler(router *mux.Router) *OpenAPIHandler {
+	return &OpenAPIHandler{router: router}
+}
+
+// InjectRoutes serves the document on router and the reference page on
+// publicRouter, whose auth middleware resolves the roles the page checks.
+func (h *OpenAPIHandler) InjectRoutes(router *mux.Router, publicRouter *mux.Router) {
+	assets, err := fs.Sub(docsFiles, "docs")
+	if err != nil {
+		panic("Failed to load API reference assets: " + err.Error())
+	}
+
+	router.HandleFunc("/openapi.json", h.OpenAPI).Methods("GET")
+	publicRouter.Handle("/docs", h.docsOnly(http.HandlerFunc(h.Docs))).Methods("GET")
+	publicRouter.PathPrefix("/docs/").Handler(h.docsOnly(http.StripPrefix("/docs/", http.FileServer(http.FS(assets))))).Methods("GET")
+
+	config.OnChange(func(prev, next *config.AppConfig) {
+		doc, err := h.build()
+		if err != nil {
+			logger.Error(context.Background(), "Failed to rebuild OpenAPI document: ", err)
+			return
+		}
+		h.doc.Store(&doc)
+	})
+}
+
+func (h *OpenAPIHandler) build() ([]byte, error) {
+	list, err := routes.List(h.router)
+	if err != nil {
+		return nil, err
+	}
+	bindings, err := rest.Bindings(list, config.Config().Rest)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(openapi.Build(list, bindings))
+}
+
+func (h *OpenAPIHandler) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	doc := h.doc.Load()
+	if doc == nil {
+		built, err := h.build()
+		if err != nil {
+			logger.Error(r.Context(), "Failed to build OpenAPI document: ", err)
+			http.Error(w, "Failed to build OpenAPI document", http.StatusInternalServerError)
+			return
+		}
+		// A reload may have stored a newer document meanwhile
+		h.doc.CompareAndSwap(nil, &built)
+		doc = &built
+	}
+
+	w.Header().Set("Content-Type", constant.ContentTypeJSON)
+	_, _ = w.Write(*doc)
+}
+
+// Docs serves the API reference page.
+func (h *OpenAPIHandler) Docs(w http.ResponseWriter, r *http.Request) {
+	page, err := fs.ReadFile(docsFiles, "docs/index.html")
+	if err != nil {
+		http.Error(w, "Failed to load API reference", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(page)
+}
+
+// docsOnly serves the reference page to admins, and to everyone when
+// app.debug is enabled.
+func (h *OpenAPIHandler) docsOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !config.Config().App.Debug && !helper.HasRole(r.Context(), constant.RoleAdmin) {
+			http.NotFound(w, r)
+			return
+		}
+
+		// Swagger UI sets inline styles and uses data: images
+		w.Header().Set("Content-Security-Policy", "default-src 'self'; img-src 'self' data:; style-src 'self' 'unsafe-inline'; frame-ancestors 'none'")
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,175 @@
+package janus
+
+import (
+	"io"
+	"net/http"
+	"reflect"
+	"sync/atomic"
+
+	"github.com/cynx-io/cynx-core/src/logger"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+	"github.com/cynx-io/janus-gateway/internal/gateway/handlers"
+	"github.com/cynx-io/janus-gateway/internal/gateway/rest"
+	"github.com/cynx-io/janus-gateway/internal/gateway/routes"
+	"github.com/gorilla/mux"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+type RestHandler struct {
+	router *mux.Router
+	rest   atomic.Pointer[restRoutes]
+}
+
+var _ routes.Lister = (*RestHandler)(nil)
+
+// restRoutes is the router serving a set of bindings.
+type restRoutes struct {
+	router   *mux.Router
+	bindings []rest.Binding
+}
+
+// NewRestHandler returns a handler that serves RESTful paths by binding them
+// into the request message and dispatching to the RPC-style route on router.
+func NewRestHandler(router *mux.Router) *RestHandler {
+	return &RestHandler{router: router}
+}
+
+// InjectRoutes registers the REST bindings. It must run after every RPC route
+// is injected, since google.api.http annotations are read from those routes.
+// Bindings live on their own router so config reloads can replace them.
+func (h *RestHandler) InjectRoutes(router *mux.Router) {
+	rest, err := h.build(config.Config().Rest)
+	if err != nil {
+		panic("Failed to load REST bindings: " + err.Error())
+	}
+	h.rest.Store(rest)
+
+	router.MatcherFunc(h.match).Handler(h)
+
+	config.AddValidator(func(next *config.AppConfig) error {
+		_, err := h.build(next.Rest)
+		return err
+	})
+	config.OnChange(func(prev, next *config.AppConfig) {
+		if reflect.DeepEqual(prev.Rest, next.Rest) {
+			return
+		}
+		// Validated before the config was applied
+		if rest, err := h.build(next.Rest); err == nil {
+			h.rest.Store(rest)
+		}
+	})
+}
+
+func (h *RestHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.rest.Load().router.ServeHTTP(w, r)
+}
+
+func (h *RestHandler) match(r *http.Request, _ *mux.RouteMatch) bool {
+	var match mux.RouteMatch
+	return h.rest.Load().router.Match(r, &match) && match.MatchErr == nil
+}
+
+// Routes lists the bindings for routes.List, which cannot walk them.
+func (h *RestHandler) Routes() []routes.Route {
+	current := h.rest.Load()
+	if current == nil {
+		return nil
+	}
+
+	list := make([]routes.Route, 0, len(current.bindings))
+	for _, b := range current.bindings {
+		list = append(list, routes.Route{
+			Rpc:     b.Rpc,
+			Path:    b.Path,
+			Methods: []string{b.Verb, http.MethodOptions},
+			Rest:    true,
+		})
+	}
+	return list
+}
+
+func (h *RestHandler) build(mappings []config.RestMapping) (*restRoutes, error) {
+	list, err := routes.List(h.router)
+	if err != nil {
+		return nil, err
+	}
+
+	bindings, err := rest.Bindings(list, mappings)
+	if err != nil {
+		return nil, err
+	}
+
+	router := mux.NewRouter()
+	for _, b := range bindings {
+		router.HandleFunc(b.MuxPath(), h.serve(b)).Methods(b.Verb, http.MethodOptions)
+	}
+	return &restRoutes{router: router, bindings: bindings}, nil
+}
+
+func (h *RestHandler) serve(b rest.Binding) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		msg := dynamicpb.NewMessage(b.Rpc.Input())
+
+		if b.Body != "" {
+			if err := decodeBody(r, msg, b.Body); err != nil {
+				http.Error(w, "Invalid request", http.StatusBadRequest)
+				return
+			}
+		}
+
+		vars := mux.Vars(r)
+		values := make(map[string][]string, len(vars))
+		if b.Body != "*" {
+			for key, vals := range r.URL.Query() {
+				values[key] = vals
+			}
+		}
+		// Path variables take precedence over query parameters and body
+		for key, val := range vars {
+			values[key] = []string{val}
+		}
+
+		if err := handlers.BindValues(msg, values); err != nil {
+			http.Error(w, "Invalid request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		body, err := protojson.Marshal(msg)
+		if err != nil {
+			logger.Error(r.Context(), "Failed to marshal REST request: ", err)
+			http.Error(w, "Internal Error", http.StatusInternalServerError)
+			return
+		}
+
+		req, err := handlers.NewInternalRequest(r, b.RpcPath(), body)
+		if err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+		h.router.ServeHTTP(w, req)
+	}
+}
+
+// decodeBody reads the request body into msg, or into its field named by
+// bodyField unless that is "*".
+func decodeBody(r *http.Request, msg *dynamicpb.Message, bodyField string) error {
+	data, err := io.ReadAll(r.Body)
+	if err != nil || len(data) == 0 {
+		return err
+	}
+
+	target := protoreflect.Message(msg)
+	if bodyField != "*" {
+		// Bindings are validated on startup, so the field exists
+		target = msg.Mutable(handlers.FindField(msg.Descriptor(), bodyField)).Message()
+	}
+
+	if handlers.IsProtoContentType(r.Header.Get("Content-Type")) {
+		return proto.Unmarshal(data, target.Interface())
+	}
+	return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, target.Interface())
+}
@@ -1,25 +1,19 @@
 package janus
 
 import (
-	pb "github.com/cynx-io/janus-gateway/api/proto/gen/hermes"
-	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+	"github.com/cynx-io/janus-gateway/internal/constant"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/upstream"
 	"github.com/gorilla/mux"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
-type GatewayHandler struct {
-	userClient pb.HermesUserServiceClient
-}
+type GatewayHandler struct{}
 
 func NewGatewayHandler() *GatewayHandler {
-	conn, err := grpc.NewClient(config.Config.Hermes.Url, grpc.WithTransportCredentials(insecure.NewCredentials()))
-	if err != nil {
+	// Logins upsert their user into Hermes through bearer.UpsertUser
+	if _, err := upstream.Dial("hermes"); err != nil {
 		panic("Failed to connect to Hermes gRPC server: " + err.Error())
 	}
-
-	userClient := pb.NewHermesUserServiceClient(conn)
-	return &GatewayHandler{userClient: userClient}
+	return &GatewayHandler{}
 }
 
 func (h *GatewayHandler) InjectRoutes(router *mux.Router) {
@@ -28,7 +22,23 @@ func (h *GatewayHandler) InjectRoutes(router *mux.Router) {
 
 	auth0.HandleFunc("/login", h.Auth0Login).Methods("GET")
 	auth0.HandleFunc("/callback", h.Auth0CallbackLogin).Methods("GET")
+	auth0.HandleFunc("/silent", h.Auth0Silent).Methods("GET")
+	auth0.HandleFunc("/sso/transfer", h.Auth0SsoTransfer).Methods("POST", "OPTIONS")
+	auth0.HandleFunc("/sso", h.Auth0Sso).Methods("GET")
 	auth0.HandleFunc("/me", h.Auth0Me).Methods("GET")
 	auth0.HandleFunc("/logout", h.Auth0Logout).Methods("GET", "POST")
+	auth0.HandleFunc("/sessions", h.Auth0Sessions).Methods("GET", "OPTIONS")
+	auth0.HandleFunc("/sessions/revoke-all", h.Auth0RevokeAllSessions).Methods("POST", "OPTIONS")
+	auth0.HandleFunc("/sessions/{id}/revoke", h.Auth0RevokeSession).Methods("POST", "OPTIONS")
+
+	router.HandleFunc("/token", h.Token).Methods("POST", "OPTIONS")
+	router.HandleFunc("/.well-known/jwks.json", h.JWKS).Methods("GET").Name(constant.RouteNameSiteless)
+
+}
+
+// InjectAdminRoutes adds the routes that require the admin role.
+func (h *GatewayHandler) InjectAdminRoutes(adminRouter *mux.Router) {
+
+	adminRouter.HandleFunc("/auth0/users/{user_id}/sessions/revoke", h.RevokeUserSessions).Methods("POST", "OPTIONS")
 
 }
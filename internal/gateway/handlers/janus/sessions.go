@@ -0,0 +1,104 @@
+package janus
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/cynx-io/cynx-core/src/logger"
+	"github.com/cynx-io/janus-gateway/internal/session"
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+// Auth0Sessions lists the active sessions of the logged in user.
+func (h *GatewayHandler) Auth0Sessions(w http.ResponseWriter, r *http.Request) {
+	sessions, err := session.ListSessions(r)
+	if err != nil {
+		sessionError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	response := map[string]interface{}{
+		"sessions": sessions,
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Auth0RevokeSession logs out one session of the logged in user.
+func (h *GatewayHandler) Auth0RevokeSession(w http.ResponseWriter, r *http.Request) {
+	revoked, err := session.RevokeSession(w, r, mux.Vars(r)["id"])
+	if err != nil {
+		sessionError(w, r, err)
+		return
+	}
+	if !revoked {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	response := map[string]interface{}{
+		"success": true,
+		"revoked": 1,
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Auth0RevokeAllSessions logs out every session of the logged in user,
+// including the current one.
+func (h *GatewayHandler) Auth0RevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	revoked, err := session.RevokeAllSessions(w, r)
+	if err != nil {
+		sessionError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	response := map[string]interface{}{
+		"success": true,
+		"revoked": revoked,
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// RevokeUserSessions logs out every session of a user, for admins.
+func (h *GatewayHandler) RevokeUserSessions(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["user_id"]
+	revoked, err := session.RevokeUserSessions(r.Context(), userID)
+	if err != nil {
+		sessionError(w, r, err)
+		return
+	}
+	logger.Info(r.Context(), "[SESSION] Revoked ", revoked, " sessions of user ", userID)
+
+	w.Header().Set("Content-Type", "application/json")
+	response := map[string]interface{}{
+		"success": true,
+		"revoked": revoked,
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func sessionError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, session.ErrUnauthenticated):
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	case errors.Is(err, session.ErrNoRegistry):
+		http.Error(w, "Session management requires a server-side session store", http.StatusNotImplemented)
+	default:
+		logger.Error(r.Context(), "[SESSION] Failed to manage sessions: ", err)
+		http.Error(w, "Failed to manage sessions", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,138 @@
+package janus
+
+import (
+	"encoding/json"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/cynx-io/cynx-core/src/logger"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/auth0"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+	"github.com/cynx-io/janus-gateway/internal/helper"
+	"github.com/cynx-io/janus-gateway/internal/session"
+	"golang.org/x/oauth2"
+	"html/template"
+	"net/http"
+	"net/url"
+)
+
+// Results of a silent login
+const (
+	silentOk            = "ok"
+	silentLoginRequired = "login_required"
+	silentError         = "error"
+)
+
+// silentPage hands the result of a silent login to the page that opened it
+// in a frame or popup, or otherwise sends the browser back to the redirect
+// URL with a silent query parameter.
+var silentPage = template.Must(template.New("silent").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Janus</title></head>
+<body>
+<script>
+(function () {
+  var result = {type: "janus:silent", status: {{.Status}}, error: {{.Error}}};
+  var target = window.opener || (window.parent !== window ? window.parent : null);
+  if (target) {
+    target.postMessage(result, {{.Origin}});
+  } else {
+    window.location.replace({{.RedirectURL}});
+  }
+})();
+</script>
+</body>
+</html>
+`))
+
+// Auth0Silent checks whether the user can be logged in without interaction,
+// using an Auth0 authorization with prompt=none.
+func (h *GatewayHandler) Auth0Silent(w http.ResponseWriter, r *http.Request) {
+	siteKey, err := helper.GetSiteKey(r)
+	if err != nil {
+		http.Error(w, "Failed to get site key: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	redirectURL, err := config.Config().Sites.Get(siteKey).RedirectTarget(r.URL.Query().Get("redirect_url"))
+	if err != nil {
+		http.Error(w, "Invalid redirect_url: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Already logged in here, no need to ask Auth0
+	if userSession, err := session.GetSession(r); err == nil && userSession.Authenticated {
+		writeSilentResult(w, redirectURL, silentOk, "")
+		return
+	}
+
+	state, login, err := session.StartLogin(w, r, session.Login{RedirectURL: redirectURL, Silent: true})
+	if err != nil {
+		http.Error(w, "Failed to save session: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	authURL := auth0.Oauth2(siteKey).AuthCodeURL(state,
+		oauth2.SetAuthURLParam("prompt", "none"),
+		oauth2.S256ChallengeOption(login.Verifier),
+		oidc.Nonce(login.Nonce),
+	)
+	http.Redirect(w, r, authURL, http.StatusTemporaryRedirect)
+}
+
+// silentCallback finishes a silent login: Auth0 either sends a code, which
+// logs the session in, or an error saying the user has to log in.
+func (h *GatewayHandler) silentCallback(w http.ResponseWriter, r *http.Request, login *session.Login) {
+	if authErr := r.URL.Query().Get("error"); authErr != "" {
+		status := silentError
+		switch authErr {
+		case "login_required", "interaction_required", "consent_required":
+			status = silentLoginRequired
+		}
+		logger.Debug(r.Context(), "[AUTH0] Silent login not possible: ", authErr)
+		writeSilentResult(w, login.RedirectURL, status, authErr)
+		return
+	}
+
+	if _, failure := h.completeLogin(w, r, login); failure != nil {
+		logger.Error(r.Context(), "[AUTH0] Silent login failed: ", failure.message)
+		writeSilentResult(w, login.RedirectURL, silentError, "server_error")
+		return
+	}
+	writeSilentResult(w, login.RedirectURL, silentOk, "")
+}
+
+// writeSilentResult responds with the silent page for redirectURL, or with
+// JSON if there is nowhere to report to.
+func writeSilentResult(w http.ResponseWriter, redirectURL string, status string, authErr string) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	target, err := url.Parse(redirectURL)
+	if redirectURL == "" || err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		response := map[string]interface{}{
+			"status": status,
+			"error":  authErr,
+		}
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	origin := target.Scheme + "://" + target.Host
+	query := target.Query()
+	query.Set("silent", status)
+	target.RawQuery = query.Encode()
+
+	// Only the frontend may frame the page
+	w.Header().Set("Content-Security-Policy", "frame-ancestors "+origin)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	data := map[string]string{
+		"Status":      status,
+		"Error":       authErr,
+		"Origin":      origin,
+		"RedirectURL": target.String(),
+	}
+	if err := silentPage.Execute(w, data); err != nil {
+		http.Error(w, "Failed to render response", http.StatusInternalServerError)
+	}
+}
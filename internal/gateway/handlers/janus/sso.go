@@ -0,0 +1,124 @@
+package janus
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/cynx-io/cynx-core/src/logger"
+	"github.com/cynx-io/janus-gateway/internal/constant"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+	"github.com/cynx-io/janus-gateway/internal/helper"
+	"github.com/cynx-io/janus-gateway/internal/session"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Auth0SsoTransfer mints a transfer code that logs the user of this site in on
+// another site, and returns the URL on that site that redeems it.
+func (h *GatewayHandler) Auth0SsoTransfer(w http.ResponseWriter, r *http.Request) {
+	from, err := helper.GetSiteKey(r)
+	if err != nil {
+		http.Error(w, "Failed to get site key: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	to := constant.SiteKey(r.FormValue("site"))
+	sites := config.Config().Sites
+	if !sites.AllowsSso(from, to) {
+		http.Error(w, "Forbidden, cannot transfer to site "+string(to), http.StatusForbidden)
+		return
+	}
+	target := sites.Get(to)
+
+	redirectURL, err := target.RedirectTarget(r.FormValue("redirect_url"))
+	if err != nil {
+		http.Error(w, "Invalid redirect_url: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	code, err := session.NewTransfer(r, to, redirectURL)
+	if errors.Is(err, session.ErrUnauthenticated) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		logger.Error(r.Context(), "[SSO] Failed to create transfer code: ", err)
+		http.Error(w, "Failed to create transfer code", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	response := map[string]interface{}{
+		"code":       code,
+		"url":        strings.TrimSuffix(target.ApiUrl, "/") + "/auth0/sso?code=" + url.QueryEscape(code),
+		"expires_in": int(session.TransferTtl.Seconds()),
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Auth0Sso redeems a transfer code from another site, logs the session in and
+// redirects to the frontend.
+func (h *GatewayHandler) Auth0Sso(w http.ResponseWriter, r *http.Request) {
+	transfer, err := session.RedeemTransfer(r, r.URL.Query().Get("code"))
+	if errors.Is(err, session.ErrInvalidTransfer) {
+		http.Error(w, "Invalid or expired transfer code", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		logger.Error(r.Context(), "[SSO] Failed to redeem transfer code: ", err)
+		http.Error(w, "Failed to redeem transfer code", http.StatusInternalServerError)
+		return
+	}
+
+	// The pair may have been removed since the code was minted
+	sites := config.Config().Sites
+	if !sites.AllowsSso(transfer.From, transfer.To) {
+		http.Error(w, "Forbidden, cannot transfer from site "+string(transfer.From), http.StatusForbidden)
+		return
+	}
+
+	// Only the site that minted the code may send the browser here, so
+	// another page cannot log the browser in as someone else
+	if !fromSite(r, sites.Get(transfer.From)) {
+		logger.Info(r.Context(), "[SSO] Rejected transfer from ", transfer.From, " with referer ", r.Referer())
+		http.Error(w, "Forbidden, transfer must come from site "+string(transfer.From), http.StatusForbidden)
+		return
+	}
+
+	if err := transfer.Accept(w, r); err != nil {
+		http.Error(w, "Failed to save session: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	logger.Info(r.Context(), "[SSO] Transferred user ", transfer.UserID, " from ", transfer.From, " to ", transfer.To)
+
+	if transfer.RedirectURL == "" {
+		w.Header().Set("Content-Type", "application/json")
+		response := map[string]interface{}{
+			"success": true,
+			"message": "Login successful",
+		}
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		}
+		return
+	}
+	http.Redirect(w, r, transfer.RedirectURL, http.StatusTemporaryRedirect)
+}
+
+// fromSite reports whether the Referer of r is a page of site.
+func fromSite(r *http.Request, site config.SiteConfig) bool {
+	referer, err := url.Parse(r.Referer())
+	if err != nil || referer.Scheme == "" || referer.Host == "" {
+		return false
+	}
+	origin := referer.Scheme + "://" + referer.Host
+	if site.AllowsOrigin(origin) {
+		return true
+	}
+	frontend, err := url.Parse(site.Auth0.FrontendUrl)
+	return err == nil && site.Auth0.FrontendUrl != "" && origin == frontend.Scheme+"://"+frontend.Host
+}
@@ -0,0 +1,195 @@
+package janus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/cynx-io/cynx-core/src/logger"
+	"github.com/cynx-io/cynx-core/src/types/usertype"
+	"github.com/cynx-io/janus-gateway/internal/bearer"
+	"github.com/cynx-io/janus-gateway/internal/constant"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/auth0"
+	"github.com/cynx-io/janus-gateway/internal/helper"
+	"github.com/cynx-io/janus-gateway/internal/session"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// grantTypeSession exchanges the caller's session cookie, for web
+	// clients handing a token to an extension or a backend
+	grantTypeSession           = "session"
+	grantTypeAuthorizationCode = "authorization_code"
+	grantTypeRefreshToken      = "refresh_token"
+)
+
+type tokenRequest struct {
+	GrantType    string `json:"grant_type"`
+	Code         string `json:"code"`
+	RedirectUri  string `json:"redirect_uri"`
+	CodeVerifier string `json:"code_verifier"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Token issues gateway tokens in exchange for a session cookie, an Auth0
+// authorization code from a native client, or a refresh token. The body is
+// form or JSON encoded and errors follow OAuth 2.0 (RFC 6749 section 5.2).
+func (h *GatewayHandler) Token(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req, err := decodeTokenRequest(r)
+	if err != nil {
+		tokenError(w, "invalid_request", "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	siteKey, err := helper.GetSiteKey(r)
+	if err != nil {
+		tokenError(w, "invalid_request", "Failed to get site key: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var resp *bearer.TokenResponse
+	switch req.GrantType {
+	case grantTypeSession:
+		userSession, sessionErr := session.GetSession(r)
+		if sessionErr != nil || !userSession.Authenticated {
+			tokenError(w, "invalid_grant", "No valid session", http.StatusBadRequest)
+			return
+		}
+		userID, _ := strconv.ParseInt(userSession.UserID, 10, 32)
+		resp, err = bearer.Issue(ctx, siteKey, bearer.User{
+			Name:     userSession.Name,
+			Email:    userSession.Email,
+			Roles:    userSession.Roles,
+			UserId:   int32(userID),
+			UserType: usertype.Normal,
+		}, &bearer.Grant{
+			Subject:    userSession.Subject,
+			Email:      userSession.Email,
+			Auth0Roles: userSession.Auth0Roles,
+			Session:    session.ID(r),
+		})
+	case grantTypeAuthorizationCode:
+		user, grant, exchangeErr := h.exchangeCode(ctx, siteKey, req)
+		if exchangeErr != nil {
+			logger.Info(ctx, "[TOKEN] Authorization code exchange failed: ", exchangeErr)
+			tokenError(w, "invalid_grant", "Failed to exchange code", http.StatusBadRequest)
+			return
+		}
+		resp, err = bearer.Issue(ctx, siteKey, *user, grant)
+	case grantTypeRefreshToken:
+		resp, err = bearer.Refresh(ctx, siteKey, req.RefreshToken)
+		if errors.Is(err, bearer.ErrInvalidGrant) {
+			tokenError(w, "invalid_grant", "Invalid refresh token", http.StatusBadRequest)
+			return
+		}
+	default:
+		tokenError(w, "unsupported_grant_type", "grant_type must be session, authorization_code or refresh_token", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		logger.Error(ctx, "[TOKEN] Failed to issue token: ", err)
+		tokenError(w, "server_error", "Failed to issue token", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", constant.ContentTypeJSON)
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// exchangeCode redeems an Auth0 authorization code, with the redirect URI and
+// PKCE verifier the native client used, and returns the logged in user and
+// the grant of its refresh token.
+func (h *GatewayHandler) exchangeCode(ctx context.Context, siteKey constant.SiteKey, req tokenRequest) (*bearer.User, *bearer.Grant, error) {
+	if req.Code == "" {
+		return nil, nil, errors.New("code is required")
+	}
+
+	var opts []oauth2.AuthCodeOption
+	if req.RedirectUri != "" {
+		opts = append(opts, oauth2.SetAuthURLParam("redirect_uri", req.RedirectUri))
+	}
+	if req.CodeVerifier != "" {
+		opts = append(opts, oauth2.VerifierOption(req.CodeVerifier))
+	}
+
+	token, err := auth0.Oauth2(siteKey).Exchange(ctx, req.Code, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, nil, errors.New("no id_token field in token")
+	}
+	idToken, err := auth0.Verifier(siteKey).Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := bearer.UpsertUser(ctx, idToken.Subject, idToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	user.Roles = helper.MergeRoles(auth0.Roles(idToken), user.Roles)
+	return user, &bearer.Grant{
+		Subject:    idToken.Subject,
+		Email:      user.Email,
+		Auth0Roles: auth0.Roles(idToken),
+	}, nil
+}
+
+// JWKS serves the public keys that verify gateway access tokens.
+func (h *GatewayHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	jwks, err := bearer.JWKS()
+	if err != nil {
+		logger.Error(r.Context(), "[TOKEN] Failed to build JWKS: ", err)
+		http.Error(w, "Failed to build JWKS", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", constant.ContentTypeJSON)
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	if err := json.NewEncoder(w).Encode(jwks); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func decodeTokenRequest(r *http.Request) (tokenRequest, error) {
+	var req tokenRequest
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == constant.ContentTypeJSON {
+		err := json.NewDecoder(r.Body).Decode(&req)
+		return req, err
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return req, err
+	}
+	req.GrantType = r.PostForm.Get("grant_type")
+	req.Code = r.PostForm.Get("code")
+	req.RedirectUri = r.PostForm.Get("redirect_uri")
+	req.CodeVerifier = r.PostForm.Get("code_verifier")
+	req.RefreshToken = r.PostForm.Get("refresh_token")
+	return req, nil
+}
+
+func tokenError(w http.ResponseWriter, code string, description string, status int) {
+	w.Header().Set("Content-Type", constant.ContentTypeJSON)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"error":             code,
+		"error_description": description,
+	})
+}
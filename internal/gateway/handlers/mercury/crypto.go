@@ -1,12 +1,9 @@
 package mercury
 
 import (
-	"encoding/json"
 	pb "github.com/cynx-io/janus-gateway/api/proto/gen/mercury"
-	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/upstream"
 	"github.com/cynx-io/janus-gateway/internal/gateway/handlers"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 	"net/http"
 )
 
@@ -15,7 +12,7 @@ type CryptoHandler struct {
 }
 
 func NewCryptoHandler() *CryptoHandler {
-	conn, err := grpc.NewClient(config.Config.Mercury.Url, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := upstream.Dial("mercury")
 	if err != nil {
 		panic("Failed to connect to Mercury gRPC server: " + err.Error())
 	}
@@ -25,25 +22,19 @@ func NewCryptoHandler() *CryptoHandler {
 }
 
 func (h *CryptoHandler) SearchCoin(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		Query string `json:"query"`
-	}
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	var req pb.SearchCoinRequest
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
 
-	resp, err := h.client.SearchCoin(r.Context(), &pb.SearchCoinRequest{
-		Query: req.Query,
-	})
-
+	resp, err := h.client.SearchCoin(r.Context(), &req)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -51,25 +42,19 @@ func (h *CryptoHandler) SearchCoin(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *CryptoHandler) GetCoinRisk(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		CoinId string `json:"coin_id"`
-	}
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	var req pb.GetCoinRiskRequest
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
 
-	resp, err := h.client.GetCoinRisk(r.Context(), &pb.GetCoinRiskRequest{
-		CoinId: req.CoinId,
-	})
-
+	resp, err := h.client.GetCoinRisk(r.Context(), &req)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -1,11 +1,16 @@
 package mercury
 
-import "github.com/gorilla/mux"
+import (
+	"net/http"
+
+	"github.com/cynx-io/janus-gateway/internal/gateway/handlers"
+	"github.com/gorilla/mux"
+)
 
 func (h *CryptoHandler) InjectRoutes(publicRouter *mux.Router, privateRouter *mux.Router) {
 	public := publicRouter.PathPrefix("/mercury.MercuryCryptoService").Subrouter()
 	_ = privateRouter.PathPrefix("/mercury.MercuryCryptoService").Subrouter()
 
-	public.HandleFunc("/SearchCoin", h.SearchCoin)
-	public.HandleFunc("/GetCoinRisk", h.GetCoinRisk)
+	public.Handle("/SearchCoin", handlers.AllowGet(h.SearchCoin)).Methods(http.MethodGet, http.MethodPost, http.MethodOptions)
+	public.Handle("/GetCoinRisk", handlers.AllowGet(h.GetCoinRisk)).Methods(http.MethodGet, http.MethodPost, http.MethodOptions)
 }
@@ -1,15 +1,11 @@
 package philyra
 
 import (
-	"encoding/json"
 	"log"
 	"net/http"
 
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
-
 	pb "github.com/cynx-io/janus-gateway/api/proto/gen/philyra"
-	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/upstream"
 	"github.com/cynx-io/janus-gateway/internal/gateway/handlers"
 )
 
@@ -18,7 +14,7 @@ type AutoFillHandler struct {
 }
 
 func NewAutoFillHandler() *AutoFillHandler {
-	conn, err := grpc.NewClient(config.Config.Philyra.Url, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := upstream.Dial("philyra")
 	if err != nil {
 		panic("Failed to connect to Philyra gRPC server: " + err.Error())
 	}
@@ -28,7 +24,7 @@ func NewAutoFillHandler() *AutoFillHandler {
 
 func (h *AutoFillHandler) AnalyzeForm(w http.ResponseWriter, r *http.Request) {
 	var req pb.AnalyzeFormRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -38,7 +34,7 @@ func (h *AutoFillHandler) AnalyzeForm(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		log.Printf("Failed to handle response: %v", err)
 		return
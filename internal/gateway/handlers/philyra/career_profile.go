@@ -1,15 +1,11 @@
 package philyra
 
 import (
-	"encoding/json"
 	"log"
 	"net/http"
 
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
-
 	pb "github.com/cynx-io/janus-gateway/api/proto/gen/philyra"
-	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/upstream"
 	"github.com/cynx-io/janus-gateway/internal/gateway/handlers"
 )
 
@@ -18,7 +14,7 @@ type CareerProfileHandler struct {
 }
 
 func NewCareerProfileHandler() *CareerProfileHandler {
-	conn, err := grpc.NewClient(config.Config.Philyra.Url, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := upstream.Dial("philyra")
 	if err != nil {
 		panic("Failed to connect to Philyra gRPC server: " + err.Error())
 	}
@@ -28,7 +24,7 @@ func NewCareerProfileHandler() *CareerProfileHandler {
 
 func (h *CareerProfileHandler) GetCareerProfile(w http.ResponseWriter, r *http.Request) {
 	req := pb.GetCareerProfileRequest{}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -38,7 +34,7 @@ func (h *CareerProfileHandler) GetCareerProfile(w http.ResponseWriter, r *http.R
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		log.Printf("Failed to handle response: %v", err)
 		return
@@ -47,7 +43,7 @@ func (h *CareerProfileHandler) GetCareerProfile(w http.ResponseWriter, r *http.R
 
 func (h *CareerProfileHandler) SyncCareerProfile(w http.ResponseWriter, r *http.Request) {
 	var req pb.SyncCareerProfileRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -57,7 +53,7 @@ func (h *CareerProfileHandler) SyncCareerProfile(w http.ResponseWriter, r *http.
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -66,7 +62,7 @@ func (h *CareerProfileHandler) SyncCareerProfile(w http.ResponseWriter, r *http.
 
 func (h *CareerProfileHandler) UpdatePersonalInfo(w http.ResponseWriter, r *http.Request) {
 	var req pb.UpdateCareerPersonalInfoRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -76,7 +72,7 @@ func (h *CareerProfileHandler) UpdatePersonalInfo(w http.ResponseWriter, r *http
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -85,7 +81,7 @@ func (h *CareerProfileHandler) UpdatePersonalInfo(w http.ResponseWriter, r *http
 
 func (h *CareerProfileHandler) UpdateProfessionalInfo(w http.ResponseWriter, r *http.Request) {
 	var req pb.UpdateCareerProfessionalInfoRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -95,7 +91,7 @@ func (h *CareerProfileHandler) UpdateProfessionalInfo(w http.ResponseWriter, r *
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -104,7 +100,7 @@ func (h *CareerProfileHandler) UpdateProfessionalInfo(w http.ResponseWriter, r *
 
 func (h *CareerProfileHandler) UpdateJobPreferences(w http.ResponseWriter, r *http.Request) {
 	var req pb.UpdateCareerJobPreferencesRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -114,7 +110,7 @@ func (h *CareerProfileHandler) UpdateJobPreferences(w http.ResponseWriter, r *ht
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -123,7 +119,7 @@ func (h *CareerProfileHandler) UpdateJobPreferences(w http.ResponseWriter, r *ht
 
 func (h *CareerProfileHandler) UpdateUserDocuments(w http.ResponseWriter, r *http.Request) {
 	var req pb.UpdateCareerDocumentsRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -133,7 +129,7 @@ func (h *CareerProfileHandler) UpdateUserDocuments(w http.ResponseWriter, r *htt
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -142,7 +138,7 @@ func (h *CareerProfileHandler) UpdateUserDocuments(w http.ResponseWriter, r *htt
 
 func (h *CareerProfileHandler) UpdateCustomResponses(w http.ResponseWriter, r *http.Request) {
 	var req pb.UpdateCareerCustomResponsesRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -152,7 +148,7 @@ func (h *CareerProfileHandler) UpdateCustomResponses(w http.ResponseWriter, r *h
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -1,15 +1,11 @@
 package philyra
 
 import (
-	"encoding/json"
 	"log"
 	"net/http"
 
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
-
 	pb "github.com/cynx-io/janus-gateway/api/proto/gen/philyra"
-	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/upstream"
 	"github.com/cynx-io/janus-gateway/internal/gateway/handlers"
 )
 
@@ -18,7 +14,7 @@ type ResumeHandler struct {
 }
 
 func NewResumeHandler() *ResumeHandler {
-	conn, err := grpc.NewClient(config.Config.Philyra.Url, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := upstream.Dial("philyra")
 	if err != nil {
 		panic("Failed to connect to Philyra gRPC server: " + err.Error())
 	}
@@ -28,7 +24,7 @@ func NewResumeHandler() *ResumeHandler {
 
 func (h *ResumeHandler) CreateResume(w http.ResponseWriter, r *http.Request) {
 	var req pb.CreateResumeRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -38,7 +34,7 @@ func (h *ResumeHandler) CreateResume(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		log.Printf("Failed to handle response: %v", err)
 		return
@@ -47,7 +43,7 @@ func (h *ResumeHandler) CreateResume(w http.ResponseWriter, r *http.Request) {
 
 func (h *ResumeHandler) GetResume(w http.ResponseWriter, r *http.Request) {
 	req := pb.GetResumeRequest{}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -65,7 +61,7 @@ func (h *ResumeHandler) GetResume(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -74,7 +70,7 @@ func (h *ResumeHandler) GetResume(w http.ResponseWriter, r *http.Request) {
 
 func (h *ResumeHandler) UpdateResume(w http.ResponseWriter, r *http.Request) {
 	var req pb.UpdateResumeRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -84,7 +80,7 @@ func (h *ResumeHandler) UpdateResume(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -95,7 +91,7 @@ func (h *ResumeHandler) DeleteResume(w http.ResponseWriter, r *http.Request) {
 
 	req := pb.DeleteResumeRequest{}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -105,7 +101,7 @@ func (h *ResumeHandler) DeleteResume(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -114,7 +110,7 @@ func (h *ResumeHandler) DeleteResume(w http.ResponseWriter, r *http.Request) {
 
 func (h *ResumeHandler) ListResumes(w http.ResponseWriter, r *http.Request) {
 	req := pb.ListResumesRequest{}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -124,7 +120,7 @@ func (h *ResumeHandler) ListResumes(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -133,7 +129,7 @@ func (h *ResumeHandler) ListResumes(w http.ResponseWriter, r *http.Request) {
 
 func (h *ResumeHandler) GenerateResume(w http.ResponseWriter, r *http.Request) {
 	req := pb.GenerateResumeRequest{}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -143,7 +139,7 @@ func (h *ResumeHandler) GenerateResume(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
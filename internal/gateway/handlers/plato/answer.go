@@ -1,12 +1,9 @@
 package plato
 
 import (
-	"encoding/json"
 	pb "github.com/cynx-io/janus-gateway/api/proto/gen/plato"
-	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/upstream"
 	"github.com/cynx-io/janus-gateway/internal/gateway/handlers"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 	"net/http"
 )
 
@@ -15,7 +12,7 @@ type AnswerHandler struct {
 }
 
 func NewAnswerHandler() *AnswerHandler {
-	conn, err := grpc.NewClient(config.Config.Plato.Url, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := upstream.Dial("plato")
 	if err != nil {
 		panic("Failed to connect to Plato gRPC server: " + err.Error())
 	}
@@ -26,7 +23,7 @@ func NewAnswerHandler() *AnswerHandler {
 
 func (h *AnswerHandler) GetAnswerById(w http.ResponseWriter, r *http.Request) {
 	req := pb.AnswerIdRequest{}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -37,7 +34,7 @@ func (h *AnswerHandler) GetAnswerById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -46,7 +43,7 @@ func (h *AnswerHandler) GetAnswerById(w http.ResponseWriter, r *http.Request) {
 
 func (h *AnswerHandler) GetDetailAnswerById(w http.ResponseWriter, r *http.Request) {
 	var req pb.AnswerIdRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -57,7 +54,7 @@ func (h *AnswerHandler) GetDetailAnswerById(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -66,7 +63,7 @@ func (h *AnswerHandler) GetDetailAnswerById(w http.ResponseWriter, r *http.Reque
 
 func (h *AnswerHandler) ListAnswersByTopicId(w http.ResponseWriter, r *http.Request) {
 	var req pb.TopicIdRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -77,7 +74,7 @@ func (h *AnswerHandler) ListAnswersByTopicId(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -86,7 +83,7 @@ func (h *AnswerHandler) ListAnswersByTopicId(w http.ResponseWriter, r *http.Requ
 
 func (h *AnswerHandler) ListDetailAnswersByTopicModeId(w http.ResponseWriter, r *http.Request) {
 	var req pb.TopicModeRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -97,7 +94,7 @@ func (h *AnswerHandler) ListDetailAnswersByTopicModeId(w http.ResponseWriter, r
 		return
 	}
 
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -106,7 +103,7 @@ func (h *AnswerHandler) ListDetailAnswersByTopicModeId(w http.ResponseWriter, r
 
 func (h *AnswerHandler) InsertAnswer(w http.ResponseWriter, r *http.Request) {
 	var req pb.InsertAnswerRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -117,7 +114,7 @@ func (h *AnswerHandler) InsertAnswer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -126,7 +123,7 @@ func (h *AnswerHandler) InsertAnswer(w http.ResponseWriter, r *http.Request) {
 
 func (h *AnswerHandler) UpdateAnswer(w http.ResponseWriter, r *http.Request) {
 	var req pb.UpdateAnswerRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -137,7 +134,7 @@ func (h *AnswerHandler) UpdateAnswer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -146,7 +143,7 @@ func (h *AnswerHandler) UpdateAnswer(w http.ResponseWriter, r *http.Request) {
 
 func (h *AnswerHandler) DeleteAnswer(w http.ResponseWriter, r *http.Request) {
 	var req pb.AnswerIdRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -157,7 +154,7 @@ func (h *AnswerHandler) DeleteAnswer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -166,7 +163,7 @@ func (h *AnswerHandler) DeleteAnswer(w http.ResponseWriter, r *http.Request) {
 
 func (h *AnswerHandler) SearchAnswers(w http.ResponseWriter, r *http.Request) {
 	var req pb.SearchAnswersRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -177,7 +174,7 @@ func (h *AnswerHandler) SearchAnswers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
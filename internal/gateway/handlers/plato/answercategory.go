@@ -1,14 +1,10 @@
 package plato
 
 import (
-	"encoding/json"
 	"net/http"
 
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
-
 	pb "github.com/cynx-io/janus-gateway/api/proto/gen/plato"
-	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/upstream"
 	"github.com/cynx-io/janus-gateway/internal/gateway/handlers"
 )
 
@@ -17,7 +13,7 @@ type AnswerCategoryHandler struct {
 }
 
 func NewAnswerCategoryHandler() *AnswerCategoryHandler {
-	conn, err := grpc.NewClient(config.Config.Plato.Url, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := upstream.Dial("plato")
 	if err != nil {
 		panic("Failed to connect to Plato gRPC server: " + err.Error())
 	}
@@ -28,7 +24,7 @@ func NewAnswerCategoryHandler() *AnswerCategoryHandler {
 
 func (h *AnswerCategoryHandler) GetAnswerCategoryById(w http.ResponseWriter, r *http.Request) {
 	req := pb.AnswerCategoryIdRequest{}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -39,7 +35,7 @@ func (h *AnswerCategoryHandler) GetAnswerCategoryById(w http.ResponseWriter, r *
 		return
 	}
 
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -48,7 +44,7 @@ func (h *AnswerCategoryHandler) GetAnswerCategoryById(w http.ResponseWriter, r *
 
 func (h *AnswerCategoryHandler) ListAnswerCategoriesByAnswerId(w http.ResponseWriter, r *http.Request) {
 	req := pb.AnswerIdRequest{}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -59,7 +55,7 @@ func (h *AnswerCategoryHandler) ListAnswerCategoriesByAnswerId(w http.ResponseWr
 		return
 	}
 
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -68,7 +64,7 @@ func (h *AnswerCategoryHandler) ListAnswerCategoriesByAnswerId(w http.ResponseWr
 
 func (h *AnswerCategoryHandler) InsertAnswerCategory(w http.ResponseWriter, r *http.Request) {
 	var req pb.InsertAnswerCategoryRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -79,7 +75,7 @@ func (h *AnswerCategoryHandler) InsertAnswerCategory(w http.ResponseWriter, r *h
 		return
 	}
 
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -88,7 +84,7 @@ func (h *AnswerCategoryHandler) InsertAnswerCategory(w http.ResponseWriter, r *h
 
 func (h *AnswerCategoryHandler) UpdateAnswerCategory(w http.ResponseWriter, r *http.Request) {
 	var req pb.UpdateAnswerCategoryRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -99,7 +95,7 @@ func (h *AnswerCategoryHandler) UpdateAnswerCategory(w http.ResponseWriter, r *h
 		return
 	}
 
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -108,7 +104,7 @@ func (h *AnswerCategoryHandler) UpdateAnswerCategory(w http.ResponseWriter, r *h
 
 func (h *AnswerCategoryHandler) DeleteAnswerCategory(w http.ResponseWriter, r *http.Request) {
 	req := pb.AnswerCategoryIdRequest{}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -119,7 +115,7 @@ func (h *AnswerCategoryHandler) DeleteAnswerCategory(w http.ResponseWriter, r *h
 		return
 	}
 
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -1,14 +1,10 @@
 package plato
 
 import (
-	"encoding/json"
 	"net/http"
 
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
-
 	pb "github.com/cynx-io/janus-gateway/api/proto/gen/plato"
-	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/upstream"
 	"github.com/cynx-io/janus-gateway/internal/gateway/handlers"
 )
 
@@ -17,7 +13,7 @@ type DailyGameHandler struct {
 }
 
 func NewDailyGameHandler() *DailyGameHandler {
-	conn, err := grpc.NewClient(config.Config.Plato.Url, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := upstream.Dial("plato")
 	if err != nil {
 		panic("Failed to connect to Plato gRPC server: " + err.Error())
 	}
@@ -30,7 +26,7 @@ func (h *DailyGameHandler) GetDetailDailyGameById(w http.ResponseWriter, r *http
 
 	req := pb.DailyGameIdRequest{}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -41,7 +37,7 @@ func (h *DailyGameHandler) GetDetailDailyGameById(w http.ResponseWriter, r *http
 		return
 	}
 
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -52,7 +48,7 @@ func (h *DailyGameHandler) GetModeDailyGameById(w http.ResponseWriter, r *http.R
 
 	req := pb.ModeIdRequest{}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -63,7 +59,7 @@ func (h *DailyGameHandler) GetModeDailyGameById(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -74,7 +70,7 @@ func (h *DailyGameHandler) GetPublicDailyGame(w http.ResponseWriter, r *http.Req
 
 	req := pb.ModeIdRequest{}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -85,7 +81,7 @@ func (h *DailyGameHandler) GetPublicDailyGame(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -94,7 +90,7 @@ func (h *DailyGameHandler) GetPublicDailyGame(w http.ResponseWriter, r *http.Req
 
 func (h *DailyGameHandler) AttemptAnswer(w http.ResponseWriter, r *http.Request) {
 	var req pb.AttemptAnswerRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -105,7 +101,7 @@ func (h *DailyGameHandler) AttemptAnswer(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -114,7 +110,7 @@ func (h *DailyGameHandler) AttemptAnswer(w http.ResponseWriter, r *http.Request)
 
 func (h *DailyGameHandler) AttemptHistory(w http.ResponseWriter, r *http.Request) {
 	var req pb.DailyGameIdRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -125,7 +121,7 @@ func (h *DailyGameHandler) AttemptHistory(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
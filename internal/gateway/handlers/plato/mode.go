@@ -1,14 +1,10 @@
 package plato
 
 import (
-	"encoding/json"
 	"net/http"
 
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
-
 	pb "github.com/cynx-io/janus-gateway/api/proto/gen/plato"
-	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/upstream"
 	"github.com/cynx-io/janus-gateway/internal/gateway/handlers"
 )
 
@@ -17,7 +13,7 @@ type ModeHandler struct {
 }
 
 func NewModeHandler() *ModeHandler {
-	conn, err := grpc.NewClient(config.Config.Plato.Url, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := upstream.Dial("plato")
 	if err != nil {
 		panic("Failed to connect to Plato gRPC server: " + err.Error())
 	}
@@ -29,7 +25,7 @@ func (h *ModeHandler) GetModeById(w http.ResponseWriter, r *http.Request) {
 
 	req := pb.ModeIdRequest{}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -39,7 +35,7 @@ func (h *ModeHandler) GetModeById(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -48,7 +44,7 @@ func (h *ModeHandler) GetModeById(w http.ResponseWriter, r *http.Request) {
 
 func (h *ModeHandler) InsertMode(w http.ResponseWriter, r *http.Request) {
 	var req pb.InsertModeRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -58,7 +54,7 @@ func (h *ModeHandler) InsertMode(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -67,7 +63,7 @@ func (h *ModeHandler) InsertMode(w http.ResponseWriter, r *http.Request) {
 
 func (h *ModeHandler) UpdateMode(w http.ResponseWriter, r *http.Request) {
 	var req pb.UpdateModeRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -77,7 +73,7 @@ func (h *ModeHandler) UpdateMode(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -88,7 +84,7 @@ func (h *ModeHandler) DeleteMode(w http.ResponseWriter, r *http.Request) {
 
 	req := pb.ModeIdRequest{}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -98,7 +94,7 @@ func (h *ModeHandler) DeleteMode(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -109,7 +105,7 @@ func (h *ModeHandler) ListModesByTopicId(w http.ResponseWriter, r *http.Request)
 
 	req := pb.TopicIdRequest{}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -119,7 +115,7 @@ func (h *ModeHandler) ListModesByTopicId(w http.ResponseWriter, r *http.Request)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -1,6 +1,11 @@
 package plato
 
-import "github.com/gorilla/mux"
+import (
+	"net/http"
+
+	"github.com/cynx-io/janus-gateway/internal/gateway/handlers"
+	"github.com/gorilla/mux"
+)
 
 func (h *AnswerHandler) InjectRoutes(publicRouter *mux.Router, privateRouter *mux.Router) {
 	public := publicRouter.PathPrefix("/plato.PlatoAnswerService").Subrouter()
@@ -30,23 +35,23 @@ func (h *AnswerCategoryHandler) InjectRoutes(publicRouter *mux.Router, privateRo
 	private.HandleFunc("/DeleteAnswerCategory", h.DeleteAnswerCategory)
 }
 
-func (h *DailyGameHandler) InjectRoutes(publicRouter *mux.Router, privateRouter *mux.Router) {
+func (h *DailyGameHandler) InjectRoutes(publicRouter *mux.Router, adminRouter *mux.Router) {
 	public := publicRouter.PathPrefix("/plato.PlatoDailyGameService").Subrouter()
-	private := privateRouter.PathPrefix("/plato.PlatoDailyGameService").Subrouter()
+	admin := adminRouter.PathPrefix("/plato.PlatoDailyGameService").Subrouter()
 
 	public.HandleFunc("/GetModeDailyGameById", h.GetModeDailyGameById)
 	public.HandleFunc("/GetPublicDailyGame", h.GetPublicDailyGame)
 	public.HandleFunc("/AttemptAnswer", h.AttemptAnswer)
 	public.HandleFunc("/AttemptHistory", h.AttemptHistory)
 
-	private.HandleFunc("/GetDetailDailyGameById", h.GetDetailDailyGameById)
+	admin.HandleFunc("/GetDetailDailyGameById", h.GetDetailDailyGameById)
 }
 
 func (h *ModeHandler) InjectRoutes(publicRouter *mux.Router, privateRouter *mux.Router) {
 	public := publicRouter.PathPrefix("/plato.PlatoModeService").Subrouter()
 	private := privateRouter.PathPrefix("/plato.PlatoModeService").Subrouter()
 
-	public.HandleFunc("/ListModesByTopicId", h.ListModesByTopicId)
+	public.Handle("/ListModesByTopicId", handlers.AllowGet(h.ListModesByTopicId)).Methods(http.MethodGet, http.MethodPost, http.MethodOptions)
 
 	private.HandleFunc("/GetModeById", h.GetModeById)
 	private.HandleFunc("/InsertMode", h.InsertMode)
@@ -54,16 +59,17 @@ func (h *ModeHandler) InjectRoutes(publicRouter *mux.Router, privateRouter *mux.
 	private.HandleFunc("/DeleteMode", h.DeleteMode)
 }
 
-func (h *TopicHandler) InjectRoutes(publicRouter *mux.Router, privateRouter *mux.Router) {
+func (h *TopicHandler) InjectRoutes(publicRouter *mux.Router, privateRouter *mux.Router, adminRouter *mux.Router) {
 	public := publicRouter.PathPrefix("/plato.PlatoTopicService").Subrouter()
 	private := privateRouter.PathPrefix("/plato.PlatoTopicService").Subrouter()
+	admin := adminRouter.PathPrefix("/plato.PlatoTopicService").Subrouter()
 
-	public.HandleFunc("/PaginateTopic", h.PaginateTopic)
+	public.Handle("/PaginateTopic", handlers.AllowGet(h.PaginateTopic)).Methods(http.MethodGet, http.MethodPost, http.MethodOptions)
 	public.HandleFunc("/GetTopicBySlug", h.GetTopicBySlug)
-	public.HandleFunc("/GetTopicById", h.GetTopicById)
+	public.Handle("/GetTopicById", handlers.AllowGet(h.GetTopicById)).Methods(http.MethodGet, http.MethodPost, http.MethodOptions)
 
 	private.HandleFunc("/InsertTopic", h.InsertTopic)
 	private.HandleFunc("/UpdateTopic", h.UpdateTopic)
-	private.HandleFunc("/DeleteTopic", h.DeleteTopic)
+	admin.HandleFunc("/DeleteTopic", h.DeleteTopic)
 	private.HandleFunc("/ListTopicsByUserId", h.ListTopicsByUserId)
 }
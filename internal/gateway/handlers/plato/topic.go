@@ -1,16 +1,12 @@
 package plato
 
 import (
-	"encoding/json"
 	"log"
 	"net/http"
 
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
-
 	pbCore "github.com/cynx-io/cynx-core/proto/gen"
 	pb "github.com/cynx-io/janus-gateway/api/proto/gen/plato"
-	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/upstream"
 	"github.com/cynx-io/janus-gateway/internal/gateway/handlers"
 )
 
@@ -19,7 +15,7 @@ type TopicHandler struct {
 }
 
 func NewTopicHandler() *TopicHandler {
-	conn, err := grpc.NewClient(config.Config.Plato.Url, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := upstream.Dial("plato")
 	if err != nil {
 		panic("Failed to connect to Plato gRPC server: " + err.Error())
 	}
@@ -29,7 +25,7 @@ func NewTopicHandler() *TopicHandler {
 
 func (h *TopicHandler) PaginateTopic(w http.ResponseWriter, r *http.Request) {
 	var req pb.PaginateRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -39,7 +35,7 @@ func (h *TopicHandler) PaginateTopic(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		log.Printf("Failed to handle response: %v", err)
 		return
@@ -48,7 +44,7 @@ func (h *TopicHandler) PaginateTopic(w http.ResponseWriter, r *http.Request) {
 
 func (h *TopicHandler) GetTopicById(w http.ResponseWriter, r *http.Request) {
 	req := pb.TopicIdRequest{}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -58,7 +54,7 @@ func (h *TopicHandler) GetTopicById(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -67,7 +63,7 @@ func (h *TopicHandler) GetTopicById(w http.ResponseWriter, r *http.Request) {
 
 func (h *TopicHandler) GetTopicBySlug(w http.ResponseWriter, r *http.Request) {
 	req := pb.SlugRequest{}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -77,7 +73,7 @@ func (h *TopicHandler) GetTopicBySlug(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -86,7 +82,7 @@ func (h *TopicHandler) GetTopicBySlug(w http.ResponseWriter, r *http.Request) {
 
 func (h *TopicHandler) InsertTopic(w http.ResponseWriter, r *http.Request) {
 	var req pb.InsertTopicRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -96,7 +92,7 @@ func (h *TopicHandler) InsertTopic(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -105,7 +101,7 @@ func (h *TopicHandler) InsertTopic(w http.ResponseWriter, r *http.Request) {
 
 func (h *TopicHandler) UpdateTopic(w http.ResponseWriter, r *http.Request) {
 	var req pb.UpdateTopicRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -115,7 +111,7 @@ func (h *TopicHandler) UpdateTopic(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -126,7 +122,7 @@ func (h *TopicHandler) DeleteTopic(w http.ResponseWriter, r *http.Request) {
 
 	req := pb.TopicIdRequest{}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -136,7 +132,7 @@ func (h *TopicHandler) DeleteTopic(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -145,7 +141,7 @@ func (h *TopicHandler) DeleteTopic(w http.ResponseWriter, r *http.Request) {
 
 func (h *TopicHandler) ListTopicsByUserId(w http.ResponseWriter, r *http.Request) {
 	req := pbCore.GenericRequest{}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -155,7 +151,7 @@ func (h *TopicHandler) ListTopicsByUserId(w http.ResponseWriter, r *http.Request
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
@@ -1,12 +1,9 @@
 package plutus
 
 import (
-	"encoding/json"
 	proto "github.com/cynx-io/janus-gateway/api/proto/gen/plutus"
-	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/upstream"
 	"github.com/cynx-io/janus-gateway/internal/gateway/handlers"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 	"net/http"
 )
 
@@ -15,7 +12,7 @@ type WebhookXenditHandler struct {
 }
 
 func NewWebhookXenditHandler() *WebhookXenditHandler {
-	conn, err := grpc.NewClient(config.Config.Plutus.Url, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := upstream.Dial("plutus")
 	if err != nil {
 		panic("Failed to connect to Ananke gRPC server: " + err.Error())
 	}
@@ -25,7 +22,7 @@ func NewWebhookXenditHandler() *WebhookXenditHandler {
 
 func (h *WebhookXenditHandler) HandlePaymentInvoice(w http.ResponseWriter, r *http.Request) {
 	var req proto.HandlePaymentInvoiceRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := handlers.DecodeRequest(r, &req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
@@ -38,7 +35,7 @@ func (h *WebhookXenditHandler) HandlePaymentInvoice(w http.ResponseWriter, r *ht
 		return
 	}
 
-	err = handlers.HandleResponse(w, resp)
+	err = handlers.HandleResponse(w, r, resp)
 	if err != nil {
 		http.Error(w, "Failed to handle response", http.StatusInternalServerError)
 		return
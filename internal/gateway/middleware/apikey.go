@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cynx-io/cynx-core/src/logger"
+	"github.com/cynx-io/janus-gateway/internal/apikey"
+	"github.com/cynx-io/janus-gateway/internal/constant"
+)
+
+// ApiKeyMiddleware authenticates requests carrying an API key and applies its
+// rate limit. The key's site becomes the request's site, so CORSMiddleware
+// accepts it without an Origin. Requests without a key pass through.
+func ApiKeyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		// Batch and REST calls dispatch internally with the caller's context,
+		// which is already authenticated and counted. The batch handler
+		// counts its items itself.
+		if _, ok := apikey.FromContext(ctx); ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		raw := apikey.FromRequest(r)
+		if raw == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key, ok := apikey.Lookup(raw)
+		if !ok {
+			logger.Info(ctx, "[API KEY] Rejected unknown API key")
+			http.Error(w, "Unauthorized, invalid API key", http.StatusUnauthorized)
+			return
+		}
+
+		allowed, remaining, reset := apikey.Allow(key)
+		apikey.SetRateLimitHeaders(w.Header(), key, remaining, reset)
+		if !allowed {
+			logger.Info(ctx, "[API KEY] Rate limit exceeded for key ", key.Id)
+			w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(reset).Seconds())+1))
+			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+			return
+		}
+
+		ctx = apikey.WithContext(ctx, key)
+		ctx = context.WithValue(ctx, constant.ContextKeySiteKey, key.Site)
+
+		logger.Debug(ctx, "[API KEY] Success set for key: ", key.Id, " (Site: ", key.Site, ")")
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -2,37 +2,27 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	contextcore "github.com/cynx-io/cynx-core/src/context"
 	"github.com/cynx-io/cynx-core/src/logger"
-	"github.com/cynx-io/cynx-core/src/types/usertype"
-	"github.com/cynx-io/janus-gateway/internal/dependencies/auth0"
+	"github.com/cynx-io/janus-gateway/internal/apikey"
+	"github.com/cynx-io/janus-gateway/internal/bearer"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
 	"github.com/cynx-io/janus-gateway/internal/helper"
 	"github.com/cynx-io/janus-gateway/internal/session"
-	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/oauth2"
 	"net/http"
 	"strconv"
 	"time"
 )
 
-type Claims struct {
-	jwt.RegisteredClaims
-	Username string            `json:"username"`
-	UserId   int32             `json:"user_id"`
-	UserType usertype.UserType `json:"user_type"`
-}
-
 func refreshToken(w http.ResponseWriter, r *http.Request, userSession *session.UserSession) error {
 	if userSession.RefreshToken == "" {
 		return &oauth2.RetrieveError{Response: &http.Response{StatusCode: 401}, Body: []byte("no refresh token")}
 	}
 
-	siteKey, _ := helper.GetSiteKey(r)
-	tokenSource := auth0.Oauth2[siteKey].TokenSource(context.Background(), &oauth2.Token{
-		RefreshToken: userSession.RefreshToken,
-	})
-
-	newToken, err := tokenSource.Token()
+	// Concurrent requests of the session share one refresh
+	newToken, err := refreshOnce(r, userSession.RefreshToken)
 	if err != nil {
 		return err
 	}
@@ -46,11 +36,114 @@ func refreshToken(w http.ResponseWriter, r *http.Request, userSession *session.U
 	return session.SetSession(w, r, userSession)
 }
 
+// needsRefresh reports whether the Auth0 access token of a session is within
+// 5 minutes of expiring. Sessions accepted from another site through SSO have
+// no Auth0 tokens, so they are never refreshed; needsRecheck covers them.
+func needsRefresh(userSession *session.UserSession) bool {
+	return userSession.AccessToken != "" && time.Now().Add(5*time.Minute).After(userSession.ExpiresAt)
+}
+
+// recheckInterval is how often the user of a session is loaded from Hermes
+// again, so a deactivated user or revoked role does not outlive it.
+const recheckInterval = 5 * time.Minute
+
+// needsRecheck reports whether the user of a session was last loaded from
+// Hermes more than recheckInterval ago.
+func needsRecheck(userSession *session.UserSession) bool {
+	return time.Since(userSession.CheckedAt) >= recheckInterval
+}
+
+// recheckUser loads the user of a session from Hermes and updates its name
+// and roles. It returns bearer.ErrUserInactive for a deactivated user, and
+// for a session without an Auth0 subject to look the user up by.
+func recheckUser(w http.ResponseWriter, r *http.Request, userSession *session.UserSession) error {
+	if userSession.Subject == "" {
+		return bearer.ErrUserInactive
+	}
+
+	user, err := bearer.ReloadUser(r.Context(), userSession.Subject, userSession.Email)
+	if err != nil {
+		return err
+	}
+
+	userSession.Name = user.Name
+	userSession.Roles = helper.MergeRoles(userSession.Auth0Roles, user.Roles)
+	userSession.CheckedAt = time.Now()
+	return session.SetSession(w, r, userSession)
+}
+
+// checkSession refreshes the tokens of a session and rechecks its user when
+// due. An error means the session can no longer be used, and
+// bearer.ErrUserInactive that it must be logged out. A Hermes outage keeps the
+// session as it is and retries on its next request.
+func checkSession(w http.ResponseWriter, r *http.Request, userSession *session.UserSession, tag string) error {
+	ctx := r.Context()
+
+	if needsRefresh(userSession) {
+		if err := refreshToken(w, r, userSession); err != nil {
+			logger.Error(ctx, tag+" Token refresh failed: "+err.Error())
+			return err
+		}
+	}
+
+	if needsRecheck(userSession) {
+		err := recheckUser(w, r, userSession)
+		if errors.Is(err, bearer.ErrUserInactive) {
+			logger.Info(ctx, tag+" User of session "+userSession.UserID+" is no longer active")
+			return err
+		}
+		if err != nil {
+			logger.Error(ctx, tag+" Failed to recheck user: "+err.Error())
+		}
+	}
+	return nil
+}
+
+// withApiKeyCaller sets the caller of a request authenticated by API key.
+// Keys have no user, so only the username identifies the caller.
+func withApiKeyCaller(ctx context.Context, key config.ApiKeyConfig) context.Context {
+	return contextcore.SetKey(ctx, contextcore.KeyUsername, "apikey:"+key.Id)
+}
+
+// authenticateBearer verifies the bearer token of r and returns the context
+// with its user. On failure it writes a 401 and returns false.
+func authenticateBearer(w http.ResponseWriter, r *http.Request, raw string, tag string) (context.Context, bool) {
+	ctx := r.Context()
+
+	user, err := bearer.Authenticate(ctx, raw)
+	if err != nil {
+		logger.Info(ctx, tag+" Invalid bearer token: "+err.Error())
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		http.Error(w, "Unauthorized, invalid bearer token", http.StatusUnauthorized)
+		return ctx, false
+	}
+
+	ctx = contextcore.SetKey(ctx, contextcore.KeyUsername, user.Name)
+	ctx = contextcore.SetUserId(ctx, user.UserId)
+	ctx = contextcore.SetUserType(ctx, int32(user.UserType))
+	ctx = helper.SetRoles(ctx, user.Roles)
+
+	logger.Debug(ctx, tag+" Success set for bearer token: "+user.Name+" (UserID: "+strconv.Itoa(int(user.UserId))+")")
+	return ctx, true
+}
+
 func PublicAuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		logger.Debug(ctx, "[PUBLIC AUTH] Processing request")
 
+		if key, ok := apikey.FromContext(ctx); ok {
+			next.ServeHTTP(w, r.WithContext(withApiKeyCaller(ctx, key)))
+			return
+		}
+
+		if raw := bearer.FromRequest(r); raw != "" {
+			if ctx, ok := authenticateBearer(w, r, raw, "[PUBLIC AUTH]"); ok {
+				next.ServeHTTP(w, r.WithContext(ctx))
+			}
+			return
+		}
+
 		userSession, err := session.GetSession(r)
 		if err != nil || !userSession.Authenticated {
 			// No session, proceed without auth
@@ -58,14 +151,15 @@ func PublicAuthMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		// Check if token needs refresh (5 min buffer)
-		if time.Now().Add(5 * time.Minute).After(userSession.ExpiresAt) {
-			if refreshErr := refreshToken(w, r, userSession); refreshErr != nil {
-				// Refresh failed, continue without auth
-				logger.Error(ctx, "[PUBLIC AUTH] Token refresh failed: "+refreshErr.Error())
-				next.ServeHTTP(w, r)
-				return
+		if checkErr := checkSession(w, r, userSession, "[PUBLIC AUTH]"); checkErr != nil {
+			if errors.Is(checkErr, bearer.ErrUserInactive) {
+				if clearErr := session.ClearSession(w, r); clearErr != nil {
+					logger.Error(ctx, "[PUBLIC AUTH] Failed to clear session: "+clearErr.Error())
+				}
 			}
+			// Continue without auth
+			next.ServeHTTP(w, r)
+			return
 		}
 
 		// Add user details to ctx (convert string UserID to int32)
@@ -73,6 +167,7 @@ func PublicAuthMiddleware(next http.Handler) http.Handler {
 		ctx = contextcore.SetKey(ctx, contextcore.KeyUsername, userSession.Name)
 		ctx = contextcore.SetUserId(ctx, int32(userID))
 		ctx = contextcore.SetUserType(ctx, 1) // Default user type
+		ctx = helper.SetRoles(ctx, userSession.Roles)
 
 		logger.Debug(ctx, "[PUBLIC AUTH] Success set for: "+userSession.Name+" (UserID: "+userSession.UserID+")")
 		next.ServeHTTP(w, r.WithContext(ctx))
@@ -84,6 +179,19 @@ func PrivateAuthMiddleware(next http.Handler) http.Handler {
 		ctx := r.Context()
 		logger.Debug(ctx, "[PRIVATE AUTH] Processing request")
 
+		if key, ok := apikey.FromContext(ctx); ok {
+			logger.Debug(ctx, "[PRIVATE AUTH] Success set for API key: "+key.Id)
+			next.ServeHTTP(w, r.WithContext(withApiKeyCaller(ctx, key)))
+			return
+		}
+
+		if raw := bearer.FromRequest(r); raw != "" {
+			if ctx, ok := authenticateBearer(w, r, raw, "[PRIVATE AUTH]"); ok {
+				next.ServeHTTP(w, r.WithContext(ctx))
+			}
+			return
+		}
+
 		userSession, err := session.GetSession(r)
 		if err != nil || !userSession.Authenticated {
 			logger.Error(ctx, "[PRIVATE AUTH] No valid session")
@@ -91,16 +199,12 @@ func PrivateAuthMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		// Check if token needs refresh (5 min buffer)
-		if time.Now().Add(5 * time.Minute).After(userSession.ExpiresAt) {
-			if refreshErr := refreshToken(w, r, userSession); refreshErr != nil {
-				logger.Error(ctx, "[PRIVATE AUTH] Token refresh failed: "+refreshErr.Error())
-				if clearErr := session.ClearSession(w, r); clearErr != nil {
-					logger.Error(ctx, "[PRIVATE AUTH] Failed to clear session: "+clearErr.Error())
-				}
-				http.Error(w, "Unauthorized, token refresh failed", http.StatusUnauthorized)
-				return
+		if checkSession(w, r, userSession, "[PRIVATE AUTH]") != nil {
+			if clearErr := session.ClearSession(w, r); clearErr != nil {
+				logger.Error(ctx, "[PRIVATE AUTH] Failed to clear session: "+clearErr.Error())
 			}
+			http.Error(w, "Unauthorized, session is no longer valid", http.StatusUnauthorized)
+			return
 		}
 
 		// Add user details to ctx (convert string UserID to int32)
@@ -108,6 +212,7 @@ func PrivateAuthMiddleware(next http.Handler) http.Handler {
 		ctx = contextcore.SetKey(ctx, contextcore.KeyUsername, userSession.Name)
 		ctx = contextcore.SetUserId(ctx, int32(userID))
 		ctx = contextcore.SetUserType(ctx, 1) // Default user type
+		ctx = helper.SetRoles(ctx, userSession.Roles)
 
 		logger.Debug(ctx, "[PRIVATE AUTH] Success set for: "+userSession.Name+" (UserID: "+userSession.UserID+")")
 		next.ServeHTTP(w, r.WithContext(ctx))
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cynx-io/janus-gateway/internal/session"
+)
+
+func TestNeedsRefresh(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		session session.UserSession
+		want    bool
+	}{
+		{"fresh token", session.UserSession{AccessToken: "a", ExpiresAt: now.Add(time.Hour)}, false},
+		{"token about to expire", session.UserSession{AccessToken: "a", ExpiresAt: now.Add(time.Minute)}, true},
+		{"expired token", session.UserSession{AccessToken: "a", ExpiresAt: now.Add(-time.Hour)}, true},
+		{"SSO session without tokens", session.UserSession{Authenticated: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := needsRefresh(&tt.session); got != tt.want {
+				t.Errorf("needsRefresh = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNeedsRecheck(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		session session.UserSession
+		want    bool
+	}{
+		{"just checked", session.UserSession{CheckedAt: now}, false},
+		{"checked a while ago", session.UserSession{CheckedAt: now.Add(-time.Hour)}, true},
+		{"never checked", session.UserSession{Authenticated: true}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := needsRecheck(&tt.session); got != tt.want {
+				t.Errorf("needsRecheck = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
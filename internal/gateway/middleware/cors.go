@@ -2,68 +2,57 @@ package middleware
 
 import (
 	"context"
+	"fmt"
 	"github.com/cynx-io/cynx-core/src/logger"
+	"github.com/cynx-io/janus-gateway/internal/apikey"
 	"github.com/cynx-io/janus-gateway/internal/constant"
 	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+	"github.com/cynx-io/janus-gateway/internal/helper"
+	"github.com/gorilla/mux"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
+// CORSMiddleware resolves the site of the request and, when CORS is enabled,
+// answers for the origins of that site. The site key is resolved whether or
+// not CORS is enabled, since SiteAccessMiddleware needs it for every RPC.
 func CORSMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		logger.Debug(ctx, "[CORS]: Processing request")
 
-		if !config.Config.CORS.Enabled {
-			next.ServeHTTP(w, r)
+		origin := r.Header.Get("Origin")
+		siteKey, allowedOrigin, err := resolveSite(r)
+		if err != nil {
+			logger.Info(ctx, "[CORS] Rejected request: ", err)
+			http.Error(w, "Forbidden, origin does not belong to the site of the API key", http.StatusForbidden)
 			return
 		}
 
-		origin := r.Header.Get("Origin")
-		logger.Debug(ctx, "CORS Middleware: Origin: "+origin)
-
-		allowedOrigin := ""
-		var siteKey constant.SiteKey
-
-		// Check Origin header for CORS requests
-		if origin != "" {
-			logger.Debug(ctx, "CORS Middleware: Origin: "+origin)
-			config.Config.Sites.Iterate(func(key constant.SiteKey, siteConfig config.SiteConfig) {
-				logger.Debug(ctx, "CORS Middleware: Checking site: "+key)
-				if allowedOrigin != "" {
-					return
-				}
-				for _, o := range siteConfig.Urls {
-					logger.Debug(ctx, "CORS Middleware: Checking url: "+o)
-					if origin == o {
-						allowedOrigin = origin
-						siteKey = key
-						break
-					}
-				}
-			})
-		} else {
-			// Check host for direct API calls (no Origin header)
-			host := "https://" + r.Host
-			logger.Debug(ctx, "CORS Middleware: Host: "+host)
-			config.Config.Sites.Iterate(func(key constant.SiteKey, siteConfig config.SiteConfig) {
-				logger.Debug(ctx, "CORS Middleware: Checking API URL for site: "+key)
-				if siteKey != "" {
-					return
-				}
-				if host == siteConfig.ApiUrl {
-					siteKey = key
-				}
-			})
+		if !config.Config().CORS.Enabled {
+			if siteKey != "" {
+				r = r.WithContext(context.WithValue(ctx, constant.ContextKeySiteKey, siteKey))
+			}
+			next.ServeHTTP(w, r)
+			return
 		}
 
 		logger.Debug(ctx, "[CORS] Allowed origin: "+allowedOrigin)
+		w.Header().Add("Vary", "Origin") // ensure caching varies by origin
 		if allowedOrigin != "" {
+			policy := config.Config().Sites.Get(siteKey).CORS
+
 			// Set only if origin is allowed, never '*'
 			w.Header().Set("Access-Control-Allow-Origin", allowedOrigin)
-			w.Header().Add("Vary", "Origin") // ensure caching varies by origin
 			w.Header().Set("Access-Control-Allow-Credentials", "true")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Connect-Protocol-Version")
+			if len(policy.ExposedHeaders) > 0 {
+				w.Header().Set("Access-Control-Expose-Headers", strings.Join(policy.ExposedHeaders, ", "))
+			}
+
+			if r.Method == http.MethodOptions {
+				setPreflightHeaders(w, r, policy)
+			}
 		}
 
 		// Handle preflight OPTIONS request early
@@ -75,7 +64,7 @@ func CORSMiddleware(next http.Handler) http.Handler {
 		logger.Debug(ctx, "[CORS] Success set for origin: "+allowedOrigin)
 
 		// Site key is required - reject requests without valid origin
-		if siteKey == "" {
+		if siteKey == "" && !isSiteless(r) {
 			logger.Debug(ctx, "[CORS] No matching site found for origin: "+origin)
 			w.WriteHeader(http.StatusForbidden)
 			return
@@ -84,3 +73,87 @@ func CORSMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), constant.ContextKeySiteKey, siteKey)))
 	})
 }
+
+// resolveSite returns the site of the request from its Origin header, the
+// API key resolved by ApiKeyMiddleware, or its Host for direct API calls, in
+// that order. allowedOrigin is the Origin if it belongs to the site. An Origin
+// outside the site of the API key is an error, so a key cannot be used from
+// another site's pages.
+func resolveSite(r *http.Request) (siteKey constant.SiteKey, allowedOrigin string, err error) {
+	ctx := r.Context()
+
+	origin := r.Header.Get("Origin")
+	logger.Debug(ctx, "CORS Middleware: Origin: "+origin)
+
+	// Check Origin header for CORS requests
+	if origin != "" {
+		config.Config().Sites.Iterate(func(key constant.SiteKey, siteConfig config.SiteConfig) {
+			logger.Debug(ctx, "CORS Middleware: Checking site: "+key)
+			if allowedOrigin != "" {
+				return
+			}
+			if siteConfig.AllowsOrigin(origin) {
+				allowedOrigin = origin
+				siteKey = key
+			}
+		})
+		if key, ok := apikey.FromContext(ctx); ok && key.Site != siteKey {
+			return "", "", fmt.Errorf("origin %s is not a url of site %s of API key %s", origin, key.Site, key.Id)
+		}
+		return siteKey, allowedOrigin, nil
+	}
+
+	// Resolved from the API key by ApiKeyMiddleware
+	if key, err := helper.GetSiteKey(r); err == nil {
+		return key, "", nil
+	}
+
+	// Check host for direct API calls (no Origin header)
+	host := "https://" + r.Host
+	logger.Debug(ctx, "CORS Middleware: Host: "+host)
+	config.Config().Sites.Iterate(func(key constant.SiteKey, siteConfig config.SiteConfig) {
+		logger.Debug(ctx, "CORS Middleware: Checking API URL for site: "+key)
+		if siteKey != "" {
+			return
+		}
+		if host == siteConfig.ApiUrl {
+			siteKey = key
+		}
+	})
+	return siteKey, "", nil
+}
+
+func isSiteless(r *http.Request) bool {
+	route := mux.CurrentRoute(r)
+	return route != nil && route.GetName() == constant.RouteNameSiteless
+}
+
+var (
+	defaultCORSMethods = []string{http.MethodGet, http.MethodPost, http.MethodOptions}
+	defaultCORSHeaders = []string{"Content-Type", "Authorization", "Connect-Protocol-Version"}
+)
+
+// setPreflightHeaders answers a preflight request from an allowed origin with
+// the site's CORS policy.
+func setPreflightHeaders(w http.ResponseWriter, r *http.Request, policy config.CORSConfig) {
+	methods := policy.Methods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	headers := policy.Headers
+	if len(headers) == 0 {
+		headers = defaultCORSHeaders
+	}
+
+	w.Header().Set("Access-Control-Allow-Methods", strings.ToUpper(strings.Join(methods, ", ")))
+	w.Header().Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+	if policy.MaxAge > 0 {
+		w.Header().Set("Access-Control-Max-Age", strconv.Itoa(policy.MaxAge))
+	}
+
+	// Private Network Access: browsers ask before a public origin may call
+	// a private address, and only proceed if the gateway opts in
+	if policy.PrivateNetwork && r.Header.Get("Access-Control-Request-Private-Network") == "true" {
+		w.Header().Set("Access-Control-Allow-Private-Network", "true")
+	}
+}
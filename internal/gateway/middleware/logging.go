@@ -3,6 +3,7 @@ package middleware
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	contextcore "github.com/cynx-io/cynx-core/src/context"
 	"github.com/cynx-io/cynx-core/src/logger"
 
@@ -37,20 +38,6 @@ func LogRequestHandler(next http.Handler) http.Handler {
 
 			baseReq := contextcore.GetBaseRequest(ctx)
 
-			var bodyMap map[string]interface{}
-			_ = json.Unmarshal(bodyBytes, &bodyMap)
-
-			if base, ok := bodyMap["base"].(map[string]interface{}); ok {
-				if ts, ok := base["timestamp"].(map[string]interface{}); ok {
-					seconds, _ := ts["seconds"].(float64)
-					nanos, _ := ts["nanos"].(float64)
-					t := time.Unix(int64(seconds), int64(nanos))
-					base["timestamp"] = t.Format(time.RFC3339Nano)
-				}
-			}
-
-			modifiedBodyBytes, _ := json.Marshal(bodyMap)
-
 			logEntry := logger.TrxEntry{
 				Timestamp:     time.Now(),
 				UserId:        baseReq.UserId,
@@ -63,7 +50,7 @@ func LogRequestHandler(next http.Handler) http.Handler {
 				Referer:       referer,
 				UserAgent:     userAgent,
 				Type:          "REQUEST",
-				Body:          json.RawMessage(modifiedBodyBytes),
+				Body:          logBody(bodyBytes),
 			}
 
 			// Log to Elastic (ignore error or handle it)
@@ -77,6 +64,20 @@ func LogRequestHandler(next http.Handler) http.Handler {
 	})
 }
 
+// logBody returns the body as raw JSON for the transaction log. Binary
+// protobuf and other non-JSON bodies are replaced by a short placeholder so the
+// log entry itself stays valid JSON.
+func logBody(body []byte) json.RawMessage {
+	if len(body) == 0 {
+		return json.RawMessage("null")
+	}
+	if json.Valid(body) {
+		return body
+	}
+	placeholder, _ := json.Marshal(fmt.Sprintf("<%d bytes non-JSON body>", len(body)))
+	return placeholder
+}
+
 type captureWriter struct {
 	http.ResponseWriter
 	body       *bytes.Buffer
@@ -131,7 +132,7 @@ func LogResponseHandler(next http.Handler) http.Handler {
 				Referer:       referer,
 				UserAgent:     userAgent,
 				Type:          "RESPONSE",
-				Body:          json.RawMessage(cw.body.Bytes()),
+				Body:          logBody(cw.body.Bytes()),
 			}
 			if err := logger.LogTrxElasticsearch(ctx, entry); err != nil {
 				logger.Error(ctx, "response logging failed", err.Error())
@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+
+	contextcore "github.com/cynx-io/cynx-core/src/context"
+	"github.com/cynx-io/cynx-core/src/logger"
+	"github.com/cynx-io/janus-gateway/internal/apikey"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+	"github.com/cynx-io/janus-gateway/internal/gateway/handlers"
+	"github.com/cynx-io/janus-gateway/internal/gateway/routes"
+	"github.com/cynx-io/janus-gateway/internal/helper"
+	"github.com/cynx-io/janus-gateway/internal/policy"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// PolicyMiddleware evaluates the policies that apply to the called RPC and
+// rejects the call with 403 unless all of them allow it. It must run after
+// the auth middleware, whose user the policies see. The request is only
+// decoded when a policy applies.
+func PolicyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		route := routes.Route{Rpc: routes.FindMethod(r.URL.Path)}
+		if route.Rpc == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var applied []config.PolicyConfig
+		for _, p := range config.Config().Policies {
+			if p.Applies(route.Name()) {
+				applied = append(applied, p)
+			}
+		}
+		if len(applied) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		r, request, err := decodeForPolicy(r, route.Rpc)
+		if err != nil {
+			logger.Info(ctx, "[POLICY] Failed to decode request for ", route.Name(), ": ", err)
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+		env := policyEnv(r, route, request)
+
+		for _, p := range applied {
+			allowed, err := evalPolicy(p, env)
+			if err != nil {
+				logger.Info(ctx, "[POLICY] Denied ", route.Name(), " by ", p.Name, ": ", err)
+				http.Error(w, "Forbidden, denied by policy", http.StatusForbidden)
+				return
+			}
+			if !allowed {
+				logger.Info(ctx, "[POLICY] Denied ", route.Name(), " by ", p.Name, ": ", p.Allow, " is false")
+				http.Error(w, "Forbidden, denied by policy", http.StatusForbidden)
+				return
+			}
+			logger.Debug(ctx, "[POLICY] Allowed ", route.Name(), " by ", p.Name)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+type compiledPolicies struct {
+	cfg   *config.AppConfig
+	exprs map[string]*policy.Expr
+}
+
+var (
+	policiesMu sync.Mutex
+	policies   compiledPolicies
+)
+
+// evalPolicy evaluates p, compiling the policies of the active config once
+// per config reload.
+func evalPolicy(p config.PolicyConfig, env map[string]any) (bool, error) {
+	policiesMu.Lock()
+	if cfg := config.Config(); policies.cfg != cfg {
+		policies = compiledPolicies{cfg: cfg, exprs: make(map[string]*policy.Expr)}
+		for _, pc := range cfg.Policies {
+			if expr, err := policy.Compile(pc.Allow); err == nil {
+				policies.exprs[pc.Name] = expr
+			}
+		}
+	}
+	expr := policies.exprs[p.Name]
+	policiesMu.Unlock()
+
+	if expr == nil {
+		return false, errors.New("policy does not compile")
+	}
+	return expr.Eval(env)
+}
+
+// decodeForPolicy decodes the request message of rpc with handlers.Decode
+// into its JSON form with proto field names. Unpopulated fields are
+// included, so a field the caller left out is its zero value rather than
+// unset. The returned request carries the decoded message for the handler,
+// so the policy and the upstream call see the same request.
+func decodeForPolicy(r *http.Request, rpc protoreflect.MethodDescriptor) (*http.Request, map[string]any, error) {
+	mt, err := protoregistry.GlobalTypes.FindMessageByName(rpc.Input().FullName())
+	if err != nil {
+		return r, nil, err
+	}
+	msg := mt.New().Interface()
+	if err := handlers.Decode(r, msg); err != nil {
+		return r, nil, err
+	}
+
+	data, err := protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}.Marshal(msg)
+	if err != nil {
+		return r, nil, err
+	}
+	request := make(map[string]any)
+	if err := json.Unmarshal(data, &request); err != nil {
+		return r, nil, err
+	}
+	return handlers.WithDecoded(r, msg), request, nil
+}
+
+// policyEnv is the environment policy expressions are evaluated against.
+func policyEnv(r *http.Request, route routes.Route, request map[string]any) map[string]any {
+	ctx := r.Context()
+
+	user := map[string]any{
+		"authenticated": false,
+		"name":          contextcore.GetKeyOrEmpty(ctx, contextcore.KeyUsername),
+		"roles":         []any{},
+	}
+	if userId := contextcore.GetUserId(ctx); userId != nil {
+		user["id"] = float64(*userId)
+		user["authenticated"] = true
+	}
+	if userType := contextcore.GetUserType(ctx); userType != nil {
+		user["type"] = float64(*userType)
+	}
+	for _, role := range helper.GetRoles(ctx) {
+		user["roles"] = append(user["roles"].([]any), role)
+	}
+
+	var apiKey any
+	if key, ok := apikey.FromContext(ctx); ok {
+		apiKey = key.Id
+	}
+	siteKey, _ := helper.GetSiteKey(r)
+
+	return map[string]any{
+		"user":    user,
+		"site":    string(siteKey),
+		"origin":  r.Header.Get("Origin"),
+		"api_key": apiKey,
+		"route":   route.Name(),
+		"request": request,
+	}
+}
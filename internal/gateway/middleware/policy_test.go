@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	pbPlato "github.com/cynx-io/janus-gateway/api/proto/gen/plato"
+	"github.com/cynx-io/janus-gateway/internal/apikey"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+	"github.com/cynx-io/janus-gateway/internal/gateway/handlers"
+	"github.com/cynx-io/janus-gateway/internal/gateway/routes"
+	"github.com/cynx-io/janus-gateway/internal/policy"
+	"github.com/gorilla/mux"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+func answerMethod(t *testing.T) protoreflect.MethodDescriptor {
+	t.Helper()
+	desc, err := protoregistry.GlobalFiles.FindDescriptorByName("plato.PlatoAnswerService")
+	if err != nil {
+		t.Fatalf("Failed to find service: %v", err)
+	}
+	return desc.(protoreflect.ServiceDescriptor).Methods().ByName("GetAnswerById")
+}
+
+func TestDecodeForPolicyEmitsUnpopulated(t *testing.T) {
+	rpc := answerMethod(t)
+
+	tests := []struct {
+		name    string
+		body    string
+		wantId  any
+		wantNil []string
+	}{
+		{name: "empty body", body: "{}", wantId: float64(0), wantNil: []string{"base"}},
+		{name: "body", body: `{"answerId": 9}`, wantId: float64(9), wantNil: []string{"base"}},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+		r.Header.Set("Content-Type", "application/json")
+		_, request, err := decodeForPolicy(r, rpc)
+		if err != nil {
+			t.Errorf("%s: decodeForPolicy failed: %v", tt.name, err)
+			continue
+		}
+		if got, ok := request["answer_id"]; !ok || got != tt.wantId {
+			t.Errorf("%s: answer_id = %v (present %v), want %v", tt.name, got, ok, tt.wantId)
+		}
+		for _, field := range tt.wantNil {
+			if got, ok := request[field]; !ok || got != nil {
+				t.Errorf("%s: %s = %v (present %v), want null", tt.name, field, got, ok)
+			}
+		}
+	}
+}
+
+// TestDecodeForPolicyMatchesHandler checks that the policy sees the request
+// message the handler sends upstream, whichever of the query and the body
+// the route binds.
+func TestDecodeForPolicyMatchesHandler(t *testing.T) {
+	rpc := answerMethod(t)
+	path := "/plato.PlatoAnswerService/GetAnswerById"
+
+	tests := []struct {
+		name     string
+		allowGet bool
+		method   string
+		wantId   int32
+	}{
+		{name: "GET to body route", method: "GET", wantId: 9},
+		{name: "POST to query route", allowGet: true, method: "POST", wantId: 9},
+		{name: "GET to query route", allowGet: true, method: "GET", wantId: 4},
+	}
+
+	for _, tt := range tests {
+		var policyId any
+		var handlerId int32
+		handler := func(w http.ResponseWriter, r *http.Request) {
+			var req pbPlato.AnswerIdRequest
+			if err := handlers.DecodeRequest(r, &req); err != nil {
+				t.Errorf("%s: DecodeRequest failed: %v", tt.name, err)
+			}
+			handlerId = req.GetAnswerId()
+		}
+
+		router := mux.NewRouter()
+		router.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				r, request, err := decodeForPolicy(r, rpc)
+				if err != nil {
+					t.Errorf("%s: decodeForPolicy failed: %v", tt.name, err)
+					return
+				}
+				policyId = request["answer_id"]
+				next.ServeHTTP(w, r)
+			})
+		})
+		// Routes are registered on service subrouters, as in the gateway
+		service := router.PathPrefix("/plato.PlatoAnswerService").Subrouter()
+		if tt.allowGet {
+			service.Handle("/GetAnswerById", handlers.AllowGet(handler))
+		} else {
+			service.HandleFunc("/GetAnswerById", handler)
+		}
+
+		r := httptest.NewRequest(tt.method, path+"?answer_id=4", strings.NewReader(`{"answerId": 9}`))
+		r.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(httptest.NewRecorder(), r)
+
+		if handlerId != tt.wantId {
+			t.Errorf("%s: handler answer_id = %d, want %d", tt.name, handlerId, tt.wantId)
+		}
+		if policyId != float64(handlerId) {
+			t.Errorf("%s: policy answer_id = %v, handler answer_id = %d", tt.name, policyId, handlerId)
+		}
+	}
+}
+
+// TestPolicyApiKeyCaller checks that an owner policy denies an API key
+// caller, which has no user id, instead of comparing two nulls.
+func TestPolicyApiKeyCaller(t *testing.T) {
+	rpc := answerMethod(t)
+	r := httptest.NewRequest("POST", "/", strings.NewReader("{}"))
+	r = r.WithContext(apikey.WithContext(r.Context(), config.ApiKeyConfig{Id: "partner", Site: "makeadle"}))
+
+	_, request, err := decodeForPolicy(r, rpc)
+	if err != nil {
+		t.Fatalf("decodeForPolicy failed: %v", err)
+	}
+	env := policyEnv(r, routes.Route{Rpc: rpc}, request)
+
+	for _, source := range []string{
+		`request.user_id == user.id`,
+		`request.base == user.id`,
+		`request.base.user_id == user.id`,
+		`!(request.answer_id != user.id)`,
+	} {
+		expr, err := policy.Compile(source)
+		if err != nil {
+			t.Fatalf("Compile(%q) failed: %v", source, err)
+		}
+		if allowed, err := expr.Eval(env); err != nil || allowed {
+			t.Errorf("Eval(%q) = %v, %v, want false", source, allowed, err)
+		}
+	}
+
+	expr, _ := policy.Compile(`api_key == "partner" && !user.authenticated`)
+	if allowed, err := expr.Eval(env); err != nil || !allowed {
+		t.Errorf("Eval(%q) = %v, %v, want true", expr, allowed, err)
+	}
+}
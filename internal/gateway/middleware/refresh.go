@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/cynx-io/cynx-core/src/logger"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/auth0"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/sessionstore"
+	"github.com/cynx-io/janus-gateway/internal/helper"
+	"golang.org/x/oauth2"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRefreshGrace = 30 * time.Second
+	refreshTimeout      = 30 * time.Second
+
+	// refreshPoll is how often a replica waiting on another one's refresh
+	// checks for its result
+	refreshPoll = 100 * time.Millisecond
+)
+
+// refreshCall is one refresh of an Auth0 refresh token. Requests that
+// present the same refresh token while it runs, or within the grace window
+// after it succeeded, share its result instead of refreshing again. With
+// refresh token rotation, a second refresh with the same token would fail,
+// and Auth0 would revoke the whole token family as reused.
+type refreshCall struct {
+	done  chan struct{}
+	token *oauth2.Token
+	err   error
+}
+
+var (
+	refreshMu    sync.Mutex
+	refreshCalls = make(map[[sha256.Size]byte]*refreshCall)
+)
+
+// refreshOnce exchanges refreshToken for a new token, at most once per
+// refresh token within this process, and across replicas sharing the
+// session store.
+func refreshOnce(r *http.Request, refreshToken string) (*oauth2.Token, error) {
+	key := sha256.Sum256([]byte(refreshToken))
+
+	refreshMu.Lock()
+	call, ok := refreshCalls[key]
+	if !ok {
+		call = &refreshCall{done: make(chan struct{})}
+		refreshCalls[key] = call
+		go call.run(r, key, refreshToken)
+	}
+	refreshMu.Unlock()
+
+	// Not bounded by the request context: a canceled request must not look
+	// like a failed refresh, which logs the session out
+	<-call.done
+	return call.token, call.err
+}
+
+// run refreshes the token detached from the request that started it, so
+// that request going away does not fail the others waiting on it.
+func (c *refreshCall) run(r *http.Request, key [sha256.Size]byte, refreshToken string) {
+	ctx, cancel := context.WithTimeout(context.Background(), refreshTimeout)
+	defer cancel()
+
+	c.token, c.err = refreshShared(ctx, sessionstore.Ephemeral(), r, hex.EncodeToString(key[:]), refreshToken)
+	close(c.done)
+
+	// Failures are not shared beyond the requests already waiting
+	grace := refreshGrace()
+	if c.err != nil {
+		grace = 0
+	}
+	time.AfterFunc(grace, func() {
+		refreshMu.Lock()
+		delete(refreshCalls, key)
+		refreshMu.Unlock()
+	})
+}
+
+// refreshShared refreshes the token under a lock in store, the ephemeral
+// store, and keeps the result there for the grace window. A replica that finds the lock
+// taken waits for the result, or for the lock to go away after a failed
+// refresh and tries itself. The hash of the refresh token is the id.
+func refreshShared(ctx context.Context, store sessionstore.Backend, r *http.Request, id string, refreshToken string) (*oauth2.Token, error) {
+	resultKey := "auth0_refresh:" + id
+	lockKey := "auth0_refresh_lock:" + id
+
+	for {
+		if data, _, err := store.Load(ctx, resultKey); err == nil {
+			var token oauth2.Token
+			if err := json.Unmarshal(data, &token); err == nil {
+				return &token, nil
+			}
+		}
+
+		locked, err := sessionstore.Add(ctx, store, lockKey, nil, refreshTimeout)
+		if err != nil {
+			return nil, err
+		}
+		if locked {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(refreshPoll):
+		}
+	}
+	defer func() {
+		if err := store.Delete(context.Background(), lockKey); err != nil {
+			logger.Error(ctx, "[AUTH0] Failed to release refresh lock: ", err)
+		}
+	}()
+
+	siteKey, _ := helper.GetSiteKey(r)
+	token, err := auth0.Oauth2(siteKey).TokenSource(ctx, &oauth2.Token{
+		RefreshToken: refreshToken,
+	}).Token()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(token)
+	if err == nil {
+		err = store.Save(ctx, resultKey, data, refreshGrace())
+	}
+	if err != nil {
+		logger.Error(ctx, "[AUTH0] Failed to share refreshed token: ", err)
+	}
+	return token, nil
+}
+
+func refreshGrace() time.Duration {
+	if seconds := config.Config().Auth0.RefreshGrace; seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return defaultRefreshGrace
+}
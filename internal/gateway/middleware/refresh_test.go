@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cynx-io/janus-gateway/internal/dependencies/sessionstore"
+	"golang.org/x/oauth2"
+)
+
+// TestRefreshSharedWaitsForLock checks that a replica finding another one's
+// refresh lock takes that replica's result instead of refreshing again.
+func TestRefreshSharedWaitsForLock(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	store := sessionstore.NewMemory()
+	id := "test-refresh-shared"
+
+	if locked, err := sessionstore.Add(ctx, store, "auth0_refresh_lock:"+id, nil, time.Minute); err != nil || !locked {
+		t.Fatalf("Add of the lock = %v, %v", locked, err)
+	}
+	go func() {
+		time.Sleep(3 * refreshPoll)
+		data, _ := json.Marshal(&oauth2.Token{AccessToken: "new-access", RefreshToken: "new-refresh"})
+		_ = store.Save(ctx, "auth0_refresh:"+id, data, time.Minute)
+		_ = store.Delete(ctx, "auth0_refresh_lock:"+id)
+	}()
+
+	token, err := refreshShared(ctx, store, httptest.NewRequest("GET", "/", nil), id, "old-refresh")
+	if err != nil {
+		t.Fatalf("refreshShared failed: %v", err)
+	}
+	if token.AccessToken != "new-access" || token.RefreshToken != "new-refresh" {
+		t.Errorf("refreshShared = %+v, want the other replica's token", token)
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"github.com/cynx-io/cynx-core/src/logger"
+	"github.com/cynx-io/janus-gateway/internal/constant"
+	"github.com/cynx-io/janus-gateway/internal/helper"
+	"net/http"
+)
+
+// AdminMiddleware rejects callers without the admin role. It must run after
+// PrivateAuthMiddleware, which places the caller's roles in the context.
+func AdminMiddleware(next http.Handler) http.Handler {
+	return requireRole(next, constant.RoleAdmin)
+}
+
+func requireRole(next http.Handler, role string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		if !helper.HasRole(ctx, role) {
+			logger.Info(ctx, "[ROLE] Rejected ", r.URL.Path, ": missing role ", role)
+			http.Error(w, "Forbidden, requires role "+role, http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/cynx-io/cynx-core/src/logger"
+	"github.com/cynx-io/janus-gateway/internal/apikey"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+	"github.com/cynx-io/janus-gateway/internal/gateway/routes"
+	"github.com/cynx-io/janus-gateway/internal/helper"
+)
+
+// SiteAccessMiddleware rejects RPC calls to services outside the services
+// allowlist of the calling site, or the routes of the calling API key. It
+// must run after CORSMiddleware, which resolves the site key; paths that are
+// not RPCs pass through.
+func SiteAccessMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		route := routes.Route{Rpc: routes.FindMethod(r.URL.Path)}
+		if route.Rpc == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		siteKey, err := helper.GetSiteKey(r)
+		if err != nil {
+			logger.Info(ctx, "[SITE] Rejected ", route.Name(), ": ", err)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		if !config.Config().Sites.Get(siteKey).Allows(route.Name()) {
+			logger.Info(ctx, "[SITE] Rejected ", route.Name(), ": not in the services allowlist of site ", siteKey)
+			http.Error(w, "Forbidden, service not allowed for this site", http.StatusForbidden)
+			return
+		}
+
+		if key, ok := apikey.FromContext(ctx); ok && !key.Allows(route.Name()) {
+			logger.Info(ctx, "[SITE] Rejected ", route.Name(), ": not in the routes of API key ", key.Id)
+			http.Error(w, "Forbidden, route not allowed for this API key", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
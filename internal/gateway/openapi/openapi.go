@@ -0,0 +1,391 @@
+package openapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cynx-io/janus-gateway/internal/apikey"
+	"github.com/cynx-io/janus-gateway/internal/constant"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+	"github.com/cynx-io/janus-gateway/internal/gateway/handlers"
+	"github.com/cynx-io/janus-gateway/internal/gateway/rest"
+	"github.com/cynx-io/janus-gateway/internal/gateway/routes"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	Version = "3.1.0"
+
+	securitySession = "session"
+	sessionCookie   = "auth-session"
+	securityApiKey  = "apiKey"
+	securityBearer  = "bearer"
+
+	baseRequestName = "core.BaseRequest"
+
+	// maxQueryDepth bounds the nesting of query parameters for recursive messages
+	maxQueryDepth = 3
+)
+
+// Build generates an OpenAPI document for every RPC route in list and every
+// REST binding onto one of them. Request and response schemas follow the
+// protojson mapping used by the gateway.
+func Build(list []routes.Route, bindings []rest.Binding) map[string]interface{} {
+	b := &builder{schemas: map[string]interface{}{}}
+
+	paths := map[string]interface{}{}
+	for _, route := range list {
+		if route.Rpc == nil || route.Rest {
+			continue
+		}
+		item := map[string]interface{}{
+			"post": b.operation(route),
+		}
+		if allowsGet(route) {
+			item["get"] = b.getOperation(route)
+		}
+		paths[route.Path] = item
+	}
+
+	for i, binding := range bindings {
+		route, ok := findRoute(list, binding.RpcPath())
+		if !ok {
+			continue
+		}
+		path := binding.PlainPath()
+		item, _ := paths[path].(map[string]interface{})
+		if item == nil {
+			item = map[string]interface{}{}
+			paths[path] = item
+		}
+		item[strings.ToLower(binding.Verb)] = b.restOperation(route, binding, i)
+	}
+
+	var servers []interface{}
+	config.Config().Sites.Iterate(func(key constant.SiteKey, site config.SiteConfig) {
+		if site.ApiUrl == "" {
+			return
+		}
+		servers = append(servers, map[string]interface{}{
+			"url":         site.ApiUrl,
+			"description": string(key),
+		})
+	})
+
+	return map[string]interface{}{
+		"openapi": Version,
+		"info": map[string]interface{}{
+			"title":   config.Config().App.Name + " API",
+			"version": "1",
+		},
+		"servers": servers,
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"schemas": b.schemas,
+			"securitySchemes": map[string]interface{}{
+				securitySession: map[string]interface{}{
+					"type": "apiKey",
+					"in":   "cookie",
+					"name": sessionCookie,
+				},
+				securityBearer: map[string]interface{}{
+					"type":         "http",
+					"scheme":       "bearer",
+					"bearerFormat": "JWT",
+				},
+				securityApiKey: map[string]interface{}{
+					"type": "apiKey",
+					"in":   "header",
+					"name": apikey.HeaderApiKey,
+				},
+			},
+		},
+	}
+}
+
+type builder struct {
+	schemas map[string]interface{}
+}
+
+func (b *builder) operation(route routes.Route) map[string]interface{} {
+	rpc := route.Rpc
+
+	op := map[string]interface{}{
+		"operationId": string(rpc.Parent().Name()) + "_" + string(rpc.Name()),
+		"tags":        []string{route.Service()},
+		"requestBody": map[string]interface{}{
+			"required": true,
+			"content":  b.content(rpc.Input()),
+		},
+		"x-janus-sites": route.Sites(),
+	}
+
+	responses := map[string]interface{}{
+		"200": map[string]interface{}{
+			"description": "OK",
+			"content":     b.content(rpc.Output()),
+		},
+		"400": map[string]interface{}{"description": "Invalid request"},
+		"403": map[string]interface{}{"description": "Origin is not a configured site, or the site or API key may not call this method"},
+	}
+
+	switch route.Access {
+	case constant.RouteAccessPrivate:
+		op["security"] = []interface{}{
+			map[string]interface{}{securitySession: []string{}},
+			map[string]interface{}{securityBearer: []string{}},
+			map[string]interface{}{securityApiKey: []string{}},
+		}
+		responses["401"] = map[string]interface{}{"description": "No valid session, bearer token or API key"}
+	case constant.RouteAccessAdmin:
+		// API keys carry no roles
+		op["security"] = []interface{}{
+			map[string]interface{}{securitySession: []string{}},
+			map[string]interface{}{securityBearer: []string{}},
+		}
+		responses["401"] = map[string]interface{}{"description": "No valid session or bearer token"}
+		responses["403"] = map[string]interface{}{"description": "Caller lacks the admin role, or origin is not a configured site or the site may not call this method"}
+	case constant.RouteAccessPublic:
+		// Session is optional, it only adds the user to the base request
+		op["security"] = []interface{}{
+			map[string]interface{}{},
+			map[string]interface{}{securitySession: []string{}},
+			map[string]interface{}{securityBearer: []string{}},
+			map[string]interface{}{securityApiKey: []string{}},
+		}
+	}
+
+	op["responses"] = responses
+	return op
+}
+
+// getOperation documents the GET form of a read-only RPC, whose request
+// fields are bound from query parameters.
+func (b *builder) getOperation(route routes.Route) map[string]interface{} {
+	op := b.operation(route)
+	delete(op, "requestBody")
+	op["operationId"] = op["operationId"].(string) + "_Get"
+	op["parameters"] = b.queryParameters(route.Rpc.Input(), "", 0)
+	return op
+}
+
+// queryParameters flattens the fields of md into query parameters, with dots
+// for nested messages. The gateway-filled base request is left out.
+func (b *builder) queryParameters(md protoreflect.MessageDescriptor, prefix string, depth int) []interface{} {
+	var params []interface{}
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fd.IsMap() || (fd.Message() != nil && fd.Message().FullName() == baseRequestName) {
+			continue
+		}
+
+		name := prefix + string(fd.Name())
+		if fd.Message() != nil && wellKnown(fd.Message()) == nil {
+			if !fd.IsList() && depth < maxQueryDepth {
+				params = append(params, b.queryParameters(fd.Message(), name+".", depth+1)...)
+			}
+			continue
+		}
+
+		params = append(params, map[string]interface{}{
+			"name":   name,
+			"in":     "query",
+			"schema": b.field(fd),
+		})
+	}
+	return params
+}
+
+// restOperation documents a REST binding onto route: path variables and, unless
+// the whole body is bound, query parameters for the other request fields.
+func (b *builder) restOperation(route routes.Route, binding rest.Binding, index int) map[string]interface{} {
+	op := b.operation(route)
+	op["operationId"] = op["operationId"].(string) + "_Rest" + strconv.Itoa(index)
+
+	var params []interface{}
+	bound := map[string]bool{binding.Body: true}
+	for _, name := range binding.Variables() {
+		bound[name] = true
+		params = append(params, map[string]interface{}{
+			"name":     name,
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]interface{}{"type": "string"},
+		})
+	}
+
+	input := route.Rpc.Input()
+	if binding.Body != "*" {
+		for _, param := range b.queryParameters(input, "", 0) {
+			if name := param.(map[string]interface{})["name"].(string); !bound[name] {
+				params = append(params, param)
+			}
+		}
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+
+	switch binding.Body {
+	case "":
+		delete(op, "requestBody")
+	case "*":
+		// The whole body is the request message
+	default:
+		// Bindings are validated when loaded, so the field exists
+		op["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  b.content(handlers.FindField(input, binding.Body).Message()),
+		}
+	}
+	return op
+}
+
+func (b *builder) content(md protoreflect.MessageDescriptor) map[string]interface{} {
+	binary := map[string]interface{}{
+		"schema": map[string]interface{}{"type": "string", "contentMediaType": constant.ContentTypeProto},
+	}
+	return map[string]interface{}{
+		constant.ContentTypeJSON:     map[string]interface{}{"schema": b.ref(md)},
+		constant.ContentTypeProto:    binary,
+		constant.ContentTypeProtobuf: binary,
+	}
+}
+
+func (b *builder) ref(md protoreflect.MessageDescriptor) map[string]interface{} {
+	if schema := wellKnown(md); schema != nil {
+		return schema
+	}
+
+	name := string(md.FullName())
+	if _, ok := b.schemas[name]; !ok {
+		// Reserve the name first so recursive messages terminate
+		b.schemas[name] = nil
+		b.schemas[name] = b.message(md)
+	}
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+func (b *builder) message(md protoreflect.MessageDescriptor) map[string]interface{} {
+	properties := map[string]interface{}{}
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		properties[string(fd.Name())] = b.field(fd)
+	}
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if md.FullName() == baseRequestName {
+		schema["readOnly"] = true
+		schema["description"] = "Filled in by the gateway, any value sent by the client is replaced."
+	}
+	return schema
+}
+
+func (b *builder) field(fd protoreflect.FieldDescriptor) map[string]interface{} {
+	switch {
+	case fd.IsMap():
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": b.singular(fd.MapValue()),
+		}
+	case fd.IsList():
+		return map[string]interface{}{
+			"type":  "array",
+			"items": b.singular(fd),
+		}
+	default:
+		return b.singular(fd)
+	}
+}
+
+func (b *builder) singular(fd protoreflect.FieldDescriptor) map[string]interface{} {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return map[string]interface{}{"type": "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return map[string]interface{}{"type": "integer", "format": "uint32"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		// protojson encodes 64-bit integers as strings
+		return map[string]interface{}{"type": "string", "format": "int64"}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return map[string]interface{}{"type": "string", "format": "uint64"}
+	case protoreflect.FloatKind:
+		return map[string]interface{}{"type": "number", "format": "float"}
+	case protoreflect.DoubleKind:
+		return map[string]interface{}{"type": "number", "format": "double"}
+	case protoreflect.StringKind:
+		return map[string]interface{}{"type": "string"}
+	case protoreflect.BytesKind:
+		return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+	case protoreflect.EnumKind:
+		var names []string
+		values := fd.Enum().Values()
+		for i := 0; i < values.Len(); i++ {
+			names = append(names, string(values.Get(i).Name()))
+		}
+		return map[string]interface{}{"type": "string", "enum": names}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return b.ref(fd.Message())
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// wellKnown returns the inline schema of well-known types that protojson
+// encodes as scalars, or nil for regular messages.
+func wellKnown(md protoreflect.MessageDescriptor) map[string]interface{} {
+	switch md.FullName() {
+	case "google.protobuf.Timestamp":
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case "google.protobuf.Duration":
+		return map[string]interface{}{"type": "string", "pattern": `^-?[0-9]+(\.[0-9]+)?s$`}
+	case "google.protobuf.FieldMask":
+		return map[string]interface{}{"type": "string"}
+	case "google.protobuf.Struct", "google.protobuf.Empty", "google.protobuf.Any":
+		return map[string]interface{}{"type": "object"}
+	case "google.protobuf.ListValue":
+		return map[string]interface{}{"type": "array"}
+	case "google.protobuf.Value":
+		return map[string]interface{}{}
+	case "google.protobuf.StringValue":
+		return map[string]interface{}{"type": "string"}
+	case "google.protobuf.BytesValue":
+		return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+	case "google.protobuf.BoolValue":
+		return map[string]interface{}{"type": "boolean"}
+	case "google.protobuf.Int32Value", "google.protobuf.UInt32Value":
+		return map[string]interface{}{"type": "integer"}
+	case "google.protobuf.Int64Value", "google.protobuf.UInt64Value":
+		return map[string]interface{}{"type": "string", "format": "int64"}
+	case "google.protobuf.FloatValue", "google.protobuf.DoubleValue":
+		return map[string]interface{}{"type": "number"}
+	default:
+		return nil
+	}
+}
+
+func findRoute(list []routes.Route, path string) (routes.Route, bool) {
+	for _, route := range list {
+		if route.Rpc != nil && !route.Rest && route.Path == path {
+			return route, true
+		}
+	}
+	return routes.Route{}, false
+}
+
+func allowsGet(route routes.Route) bool {
+	for _, method := range route.Methods {
+		if method == http.MethodGet {
+			return true
+		}
+	}
+	return false
+}
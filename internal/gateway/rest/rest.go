@@ -0,0 +1,228 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+	"github.com/cynx-io/janus-gateway/internal/gateway/handlers"
+	"github.com/cynx-io/janus-gateway/internal/gateway/routes"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Field numbers of google.api.http and google.api.HttpRule, read from the raw
+// method options so the googleapis Go package is not needed.
+const (
+	httpExtensionNumber protowire.Number = 72295728
+
+	ruleGet                protowire.Number = 2
+	rulePut                protowire.Number = 3
+	rulePost               protowire.Number = 4
+	ruleDelete             protowire.Number = 5
+	rulePatch              protowire.Number = 6
+	ruleBody               protowire.Number = 7
+	ruleCustom             protowire.Number = 8
+	ruleAdditionalBindings protowire.Number = 11
+
+	patternKind protowire.Number = 1
+	patternPath protowire.Number = 2
+)
+
+// templateVariable matches "{field}", "{field.sub}" and "{field=pattern}".
+var templateVariable = regexp.MustCompile(`\{([A-Za-z0-9_.]+)(=([^}]*))?\}`)
+
+// Binding maps an HTTP verb and path template onto an upstream RPC.
+type Binding struct {
+	Rpc  protoreflect.MethodDescriptor
+	Verb string
+	// Path is the template in google.api.http syntax, e.g. "/v1/topics/{slug}".
+	Path string
+	// Body is "*" to bind the whole body to the request, a field name to bind
+	// it to that field, or empty when the request has no body.
+	Body string
+}
+
+// RpcPath returns the RPC-style route served by the binding,
+// e.g. "/plato.PlatoTopicService/GetTopicBySlug".
+func (b Binding) RpcPath() string {
+	return "/" + string(b.Rpc.Parent().FullName()) + "/" + string(b.Rpc.Name())
+}
+
+// MuxPath converts the path template to gorilla/mux syntax.
+func (b Binding) MuxPath() string {
+	return templateVariable.ReplaceAllStringFunc(b.Path, func(v string) string {
+		m := templateVariable.FindStringSubmatch(v)
+		if m[2] == "" {
+			return "{" + m[1] + "}"
+		}
+		pattern := strings.ReplaceAll(m[3], "**", ".+")
+		pattern = strings.ReplaceAll(pattern, "*", "[^/]+")
+		return "{" + m[1] + ":" + pattern + "}"
+	})
+}
+
+// PlainPath returns the path template without variable patterns, as
+// OpenAPI writes it, e.g. "/v1/{name}" for "/v1/{name=topics/*}".
+func (b Binding) PlainPath() string {
+	return templateVariable.ReplaceAllString(b.Path, "{$1}")
+}
+
+// Variables returns the field paths bound by the path variables.
+func (b Binding) Variables() []string {
+	var vars []string
+	for _, m := range templateVariable.FindAllStringSubmatch(b.Path, -1) {
+		vars = append(vars, m[1])
+	}
+	return vars
+}
+
+// Bindings returns the REST bindings of every RPC route in list, from its
+// google.api.http annotation, followed by the mappings from config.
+func Bindings(list []routes.Route, mappings []config.RestMapping) ([]Binding, error) {
+	var bindings []Binding
+	for _, route := range list {
+		if route.Rpc != nil && !route.Rest {
+			bindings = append(bindings, annotations(route.Rpc)...)
+		}
+	}
+
+	for _, mapping := range mappings {
+		rpc := routes.FindMethod("/" + strings.TrimPrefix(mapping.Rpc, "/"))
+		if rpc == nil {
+			return nil, errors.New("rest mapping " + mapping.Method + " " + mapping.Path + ": unknown rpc " + mapping.Rpc)
+		}
+		if mapping.Method == "" || !strings.HasPrefix(mapping.Path, "/") {
+			return nil, errors.New("rest mapping for " + mapping.Rpc + ": method and absolute path are required")
+		}
+		bindings = append(bindings, Binding{
+			Rpc:  rpc,
+			Verb: strings.ToUpper(mapping.Method),
+			Path: mapping.Path,
+			Body: mapping.Body,
+		})
+	}
+
+	for _, b := range bindings {
+		if err := b.validate(); err != nil {
+			return nil, errors.New("rest binding " + b.Verb + " " + b.Path + ": " + err.Error())
+		}
+	}
+	return bindings, nil
+}
+
+// validate checks that every path variable and the body field name a field
+// of the request message.
+func (b Binding) validate() error {
+	input := b.Rpc.Input()
+	for _, m := range templateVariable.FindAllStringSubmatch(b.Path, -1) {
+		md := input
+		for _, name := range strings.Split(m[1], ".") {
+			if md == nil {
+				return errors.New("path variable " + m[1] + " is not a field path")
+			}
+			fd := handlers.FindField(md, name)
+			if fd == nil || fd.IsList() || fd.IsMap() {
+				return errors.New("path variable " + m[1] + " is not a field path")
+			}
+			md = fd.Message()
+		}
+	}
+
+	if b.Body != "" && b.Body != "*" {
+		fd := handlers.FindField(input, b.Body)
+		if fd == nil || fd.Message() == nil || fd.IsList() || fd.IsMap() {
+			return errors.New("body " + b.Body + " is not a message field")
+		}
+	}
+	return nil
+}
+
+func annotations(rpc protoreflect.MethodDescriptor) []Binding {
+	opts, ok := rpc.Options().(*descriptorpb.MethodOptions)
+	if !ok || opts == nil {
+		return nil
+	}
+	raw, err := proto.Marshal(opts)
+	if err != nil {
+		return nil
+	}
+
+	var bindings []Binding
+	forEachField(raw, func(num protowire.Number, value []byte) {
+		if num == httpExtensionNumber {
+			bindings = append(bindings, parseRule(rpc, value)...)
+		}
+	})
+	return bindings
+}
+
+func parseRule(rpc protoreflect.MethodDescriptor, raw []byte) []Binding {
+	binding := Binding{Rpc: rpc}
+	var additional []Binding
+
+	forEachField(raw, func(num protowire.Number, value []byte) {
+		switch num {
+		case ruleGet:
+			binding.Verb, binding.Path = http.MethodGet, string(value)
+		case rulePut:
+			binding.Verb, binding.Path = http.MethodPut, string(value)
+		case rulePost:
+			binding.Verb, binding.Path = http.MethodPost, string(value)
+		case ruleDelete:
+			binding.Verb, binding.Path = http.MethodDelete, string(value)
+		case rulePatch:
+			binding.Verb, binding.Path = http.MethodPatch, string(value)
+		case ruleBody:
+			binding.Body = string(value)
+		case ruleCustom:
+			forEachField(value, func(num protowire.Number, value []byte) {
+				switch num {
+				case patternKind:
+					binding.Verb = strings.ToUpper(string(value))
+				case patternPath:
+					binding.Path = string(value)
+				}
+			})
+		case ruleAdditionalBindings:
+			additional = append(additional, parseRule(rpc, value)...)
+		}
+	})
+
+	if binding.Verb == "" || binding.Path == "" {
+		return additional
+	}
+	return append([]Binding{binding}, additional...)
+}
+
+// forEachField calls fn for every length-delimited field of a wire-format
+// message, which is all google.api.HttpRule uses.
+func forEachField(raw []byte, fn func(protowire.Number, []byte)) {
+	for len(raw) > 0 {
+		num, typ, n := protowire.ConsumeTag(raw)
+		if n < 0 {
+			return
+		}
+		raw = raw[n:]
+
+		if typ == protowire.BytesType {
+			value, m := protowire.ConsumeBytes(raw)
+			if m < 0 {
+				return
+			}
+			fn(num, value)
+			raw = raw[m:]
+			continue
+		}
+
+		m := protowire.ConsumeFieldValue(num, typ, raw)
+		if m < 0 {
+			return
+		}
+		raw = raw[m:]
+	}
+}
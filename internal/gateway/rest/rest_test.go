@@ -0,0 +1,328 @@
+package rest
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	_ "github.com/cynx-io/janus-gateway/api/proto/gen/plato"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+	"github.com/cynx-io/janus-gateway/internal/gateway/routes"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// field encodes a length-delimited field, as every google.api.HttpRule field
+// is.
+func field(num protowire.Number, value string) []byte {
+	b := protowire.AppendTag(nil, num, protowire.BytesType)
+	return protowire.AppendString(b, value)
+}
+
+func rule(fields ...[]byte) string {
+	var b []byte
+	for _, f := range fields {
+		b = append(b, f...)
+	}
+	return string(b)
+}
+
+// itemService describes test.ItemService/GetItem, whose request has a scalar,
+// a nested message and a repeated field. The method carries httpRule as its
+// google.api.http option, if set.
+func itemService(t *testing.T, httpRule string) protoreflect.MethodDescriptor {
+	t.Helper()
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+	str := descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()
+
+	options := &descriptorpb.MethodOptions{}
+	if httpRule != "" {
+		options.ProtoReflect().SetUnknown(field(httpExtensionNumber, httpRule))
+	}
+
+	file := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("test/item.proto"),
+		Package: proto.String("test"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Parent"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{Name: proto.String("name"), Number: proto.Int32(1), Label: optional, Type: str},
+				},
+			},
+			{
+				Name: proto.String("ItemRequest"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{Name: proto.String("id"), Number: proto.Int32(1), Label: optional, Type: str},
+					{Name: proto.String("parent"), Number: proto.Int32(2), Label: optional, Type: descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(), TypeName: proto.String(".test.Parent")},
+					{Name: proto.String("tags"), Number: proto.Int32(3), Label: descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(), Type: str},
+				},
+			},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("ItemService"),
+			Method: []*descriptorpb.MethodDescriptorProto{{
+				Name:       proto.String("GetItem"),
+				InputType:  proto.String(".test.ItemRequest"),
+				OutputType: proto.String(".test.Parent"),
+				Options:    options,
+			}},
+		}},
+	}
+
+	fd, err := protodesc.NewFile(file, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatalf("Failed to build descriptor: %v", err)
+	}
+	return fd.Services().Get(0).Methods().Get(0)
+}
+
+// route is a binding without its Rpc, for comparing.
+type route struct{ Verb, Path, Body string }
+
+func routesOf(bindings []Binding) []route {
+	var out []route
+	for _, b := range bindings {
+		out = append(out, route{b.Verb, b.Path, b.Body})
+	}
+	return out
+}
+
+func TestParseRule(t *testing.T) {
+	rpc := itemService(t, "")
+	varint := protowire.AppendVarint(protowire.AppendTag(nil, 99, protowire.VarintType), 1)
+
+	tests := []struct {
+		name string
+		rule string
+		want []route
+	}{
+		{
+			name: "get",
+			rule: rule(field(ruleGet, "/v1/items/{id}")),
+			want: []route{{http.MethodGet, "/v1/items/{id}", ""}},
+		},
+		{
+			name: "put",
+			rule: rule(field(rulePut, "/v1/items/{id}"), field(ruleBody, "*")),
+			want: []route{{http.MethodPut, "/v1/items/{id}", "*"}},
+		},
+		{
+			name: "post with whole body",
+			rule: rule(field(rulePost, "/v1/items"), field(ruleBody, "*")),
+			want: []route{{http.MethodPost, "/v1/items", "*"}},
+		},
+		{
+			name: "delete",
+			rule: rule(field(ruleDelete, "/v1/items/{id}")),
+			want: []route{{http.MethodDelete, "/v1/items/{id}", ""}},
+		},
+		{
+			name: "patch with body field",
+			rule: rule(field(rulePatch, "/v1/items/{id}"), field(ruleBody, "parent")),
+			want: []route{{http.MethodPatch, "/v1/items/{id}", "parent"}},
+		},
+		{
+			name: "custom verb",
+			rule: rule(field(ruleCustom, rule(field(patternKind, "head"), field(patternPath, "/v1/items")))),
+			want: []route{{http.MethodHead, "/v1/items", ""}},
+		},
+		{
+			name: "additional bindings follow the main one",
+			rule: rule(
+				field(ruleGet, "/v1/items/{id}"),
+				field(ruleAdditionalBindings, rule(field(rulePost, "/v1/items:get"), field(ruleBody, "*"))),
+				field(ruleAdditionalBindings, rule(field(ruleGet, "/v1/{parent.name}/items/{id}"))),
+			),
+			want: []route{
+				{http.MethodGet, "/v1/items/{id}", ""},
+				{http.MethodPost, "/v1/items:get", "*"},
+				{http.MethodGet, "/v1/{parent.name}/items/{id}", ""},
+			},
+		},
+		{
+			name: "additional bindings without a main pattern",
+			rule: rule(field(ruleAdditionalBindings, rule(field(ruleGet, "/v1/items")))),
+			want: []route{{http.MethodGet, "/v1/items", ""}},
+		},
+		{
+			name: "fields of other types are skipped",
+			rule: string(varint) + rule(field(ruleGet, "/v1/items")),
+			want: []route{{http.MethodGet, "/v1/items", ""}},
+		},
+		{
+			name: "body without a pattern",
+			rule: rule(field(ruleBody, "*")),
+			want: nil,
+		},
+		{
+			name: "empty",
+			rule: "",
+			want: nil,
+		},
+		{
+			name: "truncated",
+			rule: rule(field(ruleGet, "/v1/items"))[:5],
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := routesOf(parseRule(rpc, []byte(tt.rule)))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseRule = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	rpc := itemService(t, rule(field(ruleGet, "/v1/items/{id}")))
+	got := routesOf(annotations(rpc))
+	if want := []route{{http.MethodGet, "/v1/items/{id}", ""}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("annotations = %v, want %v", got, want)
+	}
+
+	if got := annotations(itemService(t, "")); got != nil {
+		t.Errorf("annotations without google.api.http = %v, want none", routesOf(got))
+	}
+}
+
+func TestMuxPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/v1/items", "/v1/items"},
+		{"/v1/items/{id}", "/v1/items/{id}"},
+		{"/v1/{parent.name}/items/{id}", "/v1/{parent.name}/items/{id}"},
+		{"/v1/{id=items/*}", "/v1/{id:items/[^/]+}"},
+		{"/v1/{id=items/**}", "/v1/{id:items/.+}"},
+		{"/v1/{id=*}/tags", "/v1/{id:[^/]+}/tags"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := (Binding{Path: tt.path}).MuxPath(); got != tt.want {
+				t.Errorf("MuxPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlainPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+		vars []string
+	}{
+		{"/v1/items", "/v1/items", nil},
+		{"/v1/{parent.name}/items/{id}", "/v1/{parent.name}/items/{id}", []string{"parent.name", "id"}},
+		{"/v1/{id=items/**}", "/v1/{id}", []string{"id"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			b := Binding{Path: tt.path}
+			if got := b.PlainPath(); got != tt.want {
+				t.Errorf("PlainPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+			if got := b.Variables(); !reflect.DeepEqual(got, tt.vars) {
+				t.Errorf("Variables(%q) = %q, want %q", tt.path, got, tt.vars)
+			}
+		})
+	}
+}
+
+func TestBindings(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     string
+		mappings []config.RestMapping
+		want     []route
+		err      string
+	}{
+		{
+			name: "annotation with nested path variable and body field",
+			rule: rule(field(rulePatch, "/v1/{parent.name}/items/{id}"), field(ruleBody, "parent")),
+			want: []route{{http.MethodPatch, "/v1/{parent.name}/items/{id}", "parent"}},
+		},
+		{
+			name: "annotation then mappings",
+			rule: rule(field(ruleGet, "/v1/items/{id}")),
+			mappings: []config.RestMapping{
+				{Method: "get", Path: "/v1/topics/{slug}", Rpc: "plato.PlatoTopicService/GetTopicBySlug"},
+			},
+			want: []route{
+				{http.MethodGet, "/v1/items/{id}", ""},
+				{http.MethodGet, "/v1/topics/{slug}", ""},
+			},
+		},
+		{
+			name: "unknown path variable",
+			rule: rule(field(ruleGet, "/v1/items/{missing}")),
+			err:  "path variable missing is not a field path",
+		},
+		{
+			name: "path variable through a scalar",
+			rule: rule(field(ruleGet, "/v1/items/{id.value}")),
+			err:  "path variable id.value is not a field path",
+		},
+		{
+			name: "repeated path variable",
+			rule: rule(field(ruleGet, "/v1/items/{tags}")),
+			err:  "path variable tags is not a field path",
+		},
+		{
+			name: "scalar body",
+			rule: rule(field(rulePost, "/v1/items"), field(ruleBody, "id")),
+			err:  "body id is not a message field",
+		},
+		{
+			name: "unknown body",
+			rule: rule(field(rulePost, "/v1/items"), field(ruleBody, "missing")),
+			err:  "body missing is not a message field",
+		},
+		{
+			name:     "mapping to an unknown rpc",
+			mappings: []config.RestMapping{{Method: "GET", Path: "/v1/x", Rpc: "plato.Missing/Get"}},
+			err:      "unknown rpc",
+		},
+		{
+			name:     "mapping without a method",
+			mappings: []config.RestMapping{{Path: "/v1/x", Rpc: "/plato.PlatoTopicService/GetTopicBySlug"}},
+			err:      "method and absolute path are required",
+		},
+		{
+			name:     "mapping with a relative path",
+			mappings: []config.RestMapping{{Method: "GET", Path: "v1/x", Rpc: "plato.PlatoTopicService/GetTopicBySlug"}},
+			err:      "method and absolute path are required",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list := []routes.Route{{Path: "/auth0/login"}}
+			if tt.rule != "" {
+				list = append(list, routes.Route{Rpc: itemService(t, tt.rule)})
+			}
+
+			bindings, err := Bindings(list, tt.mappings)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Bindings error = %v, want one containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Bindings failed: %v", err)
+			}
+			if got := routesOf(bindings); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Bindings = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package routes
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/cynx-io/janus-gateway/internal/constant"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+	"github.com/gorilla/mux"
+)
+
+var (
+	middlewareMu sync.RWMutex
+	middlewares  = map[constant.RouteAccess][]string{}
+)
+
+// SetMiddleware records the middleware chain applied to routes with the given
+// access, for route listings. RouteAccessNone is the root chain every route
+// runs through first.
+func SetMiddleware(access constant.RouteAccess, mws ...mux.MiddlewareFunc) {
+	names := make([]string, 0, len(mws))
+	for _, mw := range mws {
+		name := runtime.FuncForPC(reflect.ValueOf(mw).Pointer()).Name()
+		names = append(names, name[strings.LastIndex(name, "/")+1:])
+	}
+
+	middlewareMu.Lock()
+	defer middlewareMu.Unlock()
+	middlewares[access] = names
+}
+
+// Middleware returns the middleware chain the route runs through, in order.
+func (r Route) Middleware() []string {
+	middlewareMu.RLock()
+	defer middlewareMu.RUnlock()
+
+	chain := append([]string{}, middlewares[constant.RouteAccessNone]...)
+	if r.Access != constant.RouteAccessNone {
+		chain = append(chain, middlewares[r.Access]...)
+	}
+	return chain
+}
+
+// Upstream returns the address of the gRPC server behind the route.
+func (r Route) Upstream() string {
+	return config.Config().UpstreamUrl(r.Package())
+}
+
+// Sites lists the site keys whose origins may call the route, per their
+// services allowlist.
+func (r Route) Sites() []string {
+	var keys []string
+	config.Config().Sites.Iterate(func(key constant.SiteKey, site config.SiteConfig) {
+		if r.Rpc == nil || site.Allows(r.Name()) {
+			keys = append(keys, string(key))
+		}
+	})
+	return keys
+}
+
+// Description is the JSON form of a route for the debug endpoint.
+type Description struct {
+	Path       string   `json:"path"`
+	Access     string   `json:"access"`
+	Upstream   string   `json:"upstream,omitempty"`
+	Service    string   `json:"service,omitempty"`
+	Method     string   `json:"method,omitempty"`
+	Methods    []string `json:"methods"`
+	Middleware []string `json:"middleware"`
+	Sites      []string `json:"sites"`
+}
+
+func Describe(list []Route) []Description {
+	descriptions := make([]Description, 0, len(list))
+	for _, r := range list {
+		d := Description{
+			Path:       r.Path,
+			Access:     string(r.Access),
+			Methods:    r.Methods,
+			Middleware: r.Middleware(),
+			Sites:      r.Sites(),
+		}
+		if d.Access == "" {
+			d.Access = "none"
+		}
+		if len(d.Methods) == 0 {
+			d.Methods = []string{"*"}
+		}
+		if r.Rpc != nil {
+			d.Service = r.Service()
+			d.Method = string(r.Rpc.Name())
+			d.Upstream = r.Upstream()
+		}
+		descriptions = append(descriptions, d)
+	}
+	return descriptions
+}
+
+// Print writes the routes as a table, for the "routes" command.
+func Print(w io.Writer, list []Route) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "PATH\tMETHODS\tACCESS\tUPSTREAM\tSERVICE/METHOD\tMIDDLEWARE\tSITES")
+	for _, d := range Describe(list) {
+		rpc := "-"
+		if d.Service != "" {
+			rpc = d.Service + "/" + d.Method
+		}
+		upstream := d.Upstream
+		if upstream == "" {
+			upstream = "-"
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			d.Path,
+			strings.Join(d.Methods, ","),
+			d.Access,
+			upstream,
+			rpc,
+			strings.Join(d.Middleware, " > "),
+			strings.Join(d.Sites, ","),
+		)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,131 @@
+package routes
+
+import (
+	"strings"
+
+	"github.com/cynx-io/janus-gateway/internal/constant"
+	"github.com/gorilla/mux"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// Route describes one handler registered on the gateway router.
+type Route struct {
+	// Rpc is the upstream method served by the route, nil for gateway-only
+	// routes such as /auth0/login or /batch.
+	Rpc     protoreflect.MethodDescriptor
+	Path    string
+	Access  constant.RouteAccess
+	Methods []string
+	// Rest is set for REST bindings, whose Path is a google.api.http template
+	// and which serve Rpc by dispatching to its RPC-style route.
+	Rest bool
+}
+
+// Lister is a handler serving routes that cannot be walked, since it matches
+// them itself, such as the REST bindings.
+type Lister interface {
+	Routes() []Route
+}
+
+// Service returns the full upstream service name, e.g. "plato.PlatoTopicService".
+func (r Route) Service() string {
+	if r.Rpc == nil {
+		return ""
+	}
+	return string(r.Rpc.Parent().FullName())
+}
+
+// Name returns the full method name, e.g. "plato.PlatoTopicService/GetTopicById".
+func (r Route) Name() string {
+	if r.Rpc == nil {
+		return ""
+	}
+	return r.Service() + "/" + string(r.Rpc.Name())
+}
+
+// Package returns the proto package of the upstream service, e.g. "plato".
+func (r Route) Package() string {
+	if r.Rpc == nil {
+		return ""
+	}
+	return string(r.Rpc.ParentFile().Package())
+}
+
+// List walks router and returns every route that has a handler, in
+// registration order, with the routes of Lister handlers in place of theirs.
+func List(router *mux.Router) ([]Route, error) {
+	var list []Route
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, ancestors []*mux.Route) error {
+		if route.GetHandler() == nil {
+			return nil
+		}
+
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			if lister, ok := route.GetHandler().(Lister); ok {
+				list = append(list, lister.Routes()...)
+			}
+			return nil
+		}
+
+		r := Route{
+			Path:   path,
+			Access: accessOf(route, ancestors),
+			Rpc:    FindMethod(path),
+		}
+		if methods, err := route.GetMethods(); err == nil {
+			r.Methods = methods
+		}
+
+		list = append(list, r)
+		return nil
+	})
+
+	// REST bindings run through the chain of the route they dispatch to
+	for i, r := range list {
+		if !r.Rest {
+			continue
+		}
+		for _, target := range list {
+			if !target.Rest && target.Rpc == r.Rpc {
+				list[i].Access = target.Access
+				break
+			}
+		}
+	}
+	return list, err
+}
+
+// FindMethod resolves an RPC-style path such as
+// "/plato.PlatoTopicService/GetTopicBySlug" to its method descriptor in the
+// global proto registry.
+func FindMethod(path string) protoreflect.MethodDescriptor {
+	service, method, ok := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	if !ok || strings.Contains(method, "/") {
+		return nil
+	}
+
+	desc, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil
+	}
+	sd, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil
+	}
+	return sd.Methods().ByName(protoreflect.Name(method))
+}
+
+// accessOf returns the access of the innermost named ancestor, since the
+// admin router is nested in the private router.
+func accessOf(route *mux.Route, ancestors []*mux.Route) constant.RouteAccess {
+	access := constant.RouteAccessNone
+	for _, a := range append(ancestors, route) {
+		switch name := constant.RouteAccess(a.GetName()); name {
+		case constant.RouteAccessPublic, constant.RouteAccessPrivate, constant.RouteAccessAdmin:
+			access = name
+		}
+	}
+	return access
+}
@@ -0,0 +1,45 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+
+	_ "github.com/cynx-io/janus-gateway/api/proto/gen/plato"
+	"github.com/cynx-io/janus-gateway/internal/constant"
+	"github.com/gorilla/mux"
+)
+
+type restLister []Route
+
+func (l restLister) ServeHTTP(http.ResponseWriter, *http.Request) {}
+
+func (l restLister) Routes() []Route {
+	return l
+}
+
+func TestListIncludesListerRoutes(t *testing.T) {
+	rpc := FindMethod("/plato.PlatoAnswerService/GetAnswerById")
+	if rpc == nil {
+		t.Fatal("Failed to find GetAnswerById")
+	}
+
+	router := mux.NewRouter()
+	private := router.PathPrefix("/").Name(string(constant.RouteAccessPrivate)).Subrouter()
+	private.HandleFunc("/plato.PlatoAnswerService/GetAnswerById", func(http.ResponseWriter, *http.Request) {})
+	router.MatcherFunc(func(*http.Request, *mux.RouteMatch) bool { return false }).
+		Handler(restLister{{Rpc: rpc, Path: "/v1/answers/{answer_id}", Methods: []string{"GET"}, Rest: true}})
+
+	list, err := List(router)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("List returned %d routes, want 2: %+v", len(list), list)
+	}
+	if got := list[1]; !got.Rest || got.Path != "/v1/answers/{answer_id}" || got.Rpc != rpc {
+		t.Errorf("REST route = %+v", got)
+	}
+	if got := list[1].Access; got != list[0].Access || got != constant.RouteAccessPrivate {
+		t.Errorf("REST route access = %q, want %q of its RPC route", got, list[0].Access)
+	}
+}
@@ -0,0 +1,23 @@
+package helper
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+func ClientIP(r *http.Request) string {
+	ips := r.Header.Get("X-Forwarded-For")
+	if ips != "" {
+		// The X-Forwarded-For header contains a comma-separated list of IPs
+		// The first IP in the list is the original client IP.
+		return strings.TrimSpace(strings.Split(ips, ",")[0])
+	}
+
+	// Otherwise, fallback to the remote address.
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return ip
+}
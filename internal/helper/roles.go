@@ -0,0 +1,42 @@
+package helper
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	"github.com/cynx-io/janus-gateway/internal/constant"
+)
+
+// SetRoles stores the roles of the authenticated caller in ctx.
+func SetRoles(ctx context.Context, roles []string) context.Context {
+	return context.WithValue(ctx, constant.ContextKeyRoles, roles)
+}
+
+// GetRoles returns the roles of the authenticated caller, or nil.
+func GetRoles(ctx context.Context) []string {
+	roles, _ := ctx.Value(constant.ContextKeyRoles).([]string)
+	return roles
+}
+
+// HasRole reports whether the authenticated caller has role.
+func HasRole(ctx context.Context, role string) bool {
+	return slices.Contains(GetRoles(ctx), role)
+}
+
+// MergeRoles returns the sorted union of the given role lists, e.g. the roles
+// from Auth0 claims and from the Hermes user record.
+func MergeRoles(lists ...[]string) []string {
+	seen := make(map[string]bool)
+	var roles []string
+	for _, list := range lists {
+		for _, role := range list {
+			if role != "" && !seen[role] {
+				seen[role] = true
+				roles = append(roles, role)
+			}
+		}
+	}
+	sort.Strings(roles)
+	return roles
+}
@@ -0,0 +1,521 @@
+// Package policy evaluates the authorization expressions of route policies,
+// such as
+//
+//	request.user_id == user.id
+//	site != "perintis" || "staff" in user.roles
+//
+// Expressions combine dotted paths into the environment, string, number,
+// boolean and null literals, lists in brackets, the comparisons == != < <= >
+// >=, membership with in, and the boolean operators ! && || with parentheses.
+// A path that does not exist is unset, and so is any comparison with it,
+// whatever the operator. The boolean operators treat unset as unknown: !unset
+// is unset, false && unset is false and true || unset is true. An expression
+// that is unset does not hold, so "request.user_id == user.id" is false when
+// both are missing, and so is "!(request.user_id != user.id)".
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Expr is a compiled policy expression.
+type Expr struct {
+	source string
+	root   node
+}
+
+// Compile parses an expression.
+func Compile(source string) (*Expr, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at offset %d", tok.text, tok.pos)
+	}
+	return &Expr{source: source, root: root}, nil
+}
+
+func (e *Expr) String() string {
+	return e.source
+}
+
+// Eval evaluates the expression against env, whose values are nil, bool,
+// float64, string, []any or map[string]any, as produced by encoding/json. The
+// expression must evaluate to a boolean; an unset expression is false.
+func (e *Expr) Eval(env map[string]any) (bool, error) {
+	value, err := e.root.eval(env)
+	if err != nil || value == (unset{}) {
+		return false, err
+	}
+	result, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("expression is %s, not a boolean", typeName(value))
+	}
+	return result, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")", "[", "]", ",", "."}
+
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(source); {
+		c := source[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"' || c == '\'':
+			j := i + 1
+			var sb strings.Builder
+			for ; j < len(source) && source[j] != c; j++ {
+				if source[j] == '\\' && j+1 < len(source) {
+					j++
+				}
+				sb.WriteByte(source[j])
+			}
+			if j >= len(source) {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: sb.String(), pos: i})
+			i = j + 1
+		case c >= '0' && c <= '9' || c == '-' && i+1 < len(source) && source[i+1] >= '0' && source[i+1] <= '9':
+			j := i + 1
+			for j < len(source) && (source[j] >= '0' && source[j] <= '9' || source[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: source[i:j], pos: i})
+			i = j
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			j := i + 1
+			for j < len(source) && (source[j] == '_' || source[j] >= 'a' && source[j] <= 'z' || source[j] >= 'A' && source[j] <= 'Z' || source[j] >= '0' && source[j] <= '9') {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: source[i:j], pos: i})
+			i = j
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(source[i:], op) {
+					tokens = append(tokens, token{kind: tokenOp, text: op, pos: i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q at offset %d", c, i)
+			}
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(source)}), nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) accept(kind tokenKind, text string) bool {
+	if tok := p.peek(); tok.kind == kind && tok.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) error {
+	if !p.accept(tokenOp, text) {
+		tok := p.peek()
+		return fmt.Errorf("expected %q at offset %d", text, tok.pos)
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept(tokenOp, "||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept(tokenOp, "&&") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if p.accept(tokenOp, "!") {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	tok := p.peek()
+	switch {
+	case tok.kind == tokenOp && (tok.text == "==" || tok.text == "!=" || tok.text == "<" || tok.text == "<=" || tok.text == ">" || tok.text == ">="),
+		tok.kind == tokenIdent && tok.text == "in":
+		p.next()
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		return compareNode{op: tok.text, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenString:
+		return literal{tok.text}, nil
+	case tokenNumber:
+		n, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at offset %d", tok.text, tok.pos)
+		}
+		return literal{n}, nil
+	case tokenIdent:
+		switch tok.text {
+		case "true":
+			return literal{true}, nil
+		case "false":
+			return literal{false}, nil
+		case "null":
+			return literal{nil}, nil
+		case "in":
+			return nil, fmt.Errorf("unexpected \"in\" at offset %d", tok.pos)
+		}
+		path := []string{tok.text}
+		for p.accept(tokenOp, ".") {
+			field := p.next()
+			if field.kind != tokenIdent {
+				return nil, fmt.Errorf("expected field name at offset %d", field.pos)
+			}
+			path = append(path, field.text)
+		}
+		return pathNode(path), nil
+	case tokenOp:
+		switch tok.text {
+		case "(":
+			inner, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return inner, p.expect(")")
+		case "[":
+			var items listNode
+			if p.accept(tokenOp, "]") {
+				return items, nil
+			}
+			for {
+				item, err := p.parsePrimary()
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, item)
+				if p.accept(tokenOp, "]") {
+					return items, nil
+				}
+				if err := p.expect(","); err != nil {
+					return nil, err
+				}
+			}
+		}
+	case tokenEOF:
+		return nil, errors.New("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %q at offset %d", tok.text, tok.pos)
+}
+
+type node interface {
+	eval(env map[string]any) (any, error)
+}
+
+type literal struct {
+	value any
+}
+
+func (n literal) eval(map[string]any) (any, error) {
+	return n.value, nil
+}
+
+// unset is the value of a path that does not exist, and of any comparison
+// or boolean operator whose result depends on one.
+type unset struct{}
+
+type pathNode []string
+
+func (n pathNode) eval(env map[string]any) (any, error) {
+	var value any = env
+	for _, field := range n {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return unset{}, nil
+		}
+		if value, ok = m[field]; !ok {
+			return unset{}, nil
+		}
+	}
+	return value, nil
+}
+
+type listNode []node
+
+func (n listNode) eval(env map[string]any) (any, error) {
+	items := make([]any, 0, len(n))
+	for _, item := range n {
+		value, err := item.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, value)
+	}
+	return items, nil
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n notNode) eval(env map[string]any) (any, error) {
+	b, known, err := evalBool(n.operand, env, "!")
+	if err != nil || !known {
+		return unset{}, err
+	}
+	return !b, nil
+}
+
+type andNode struct {
+	left, right node
+}
+
+func (n andNode) eval(env map[string]any) (any, error) {
+	left, leftKnown, err := evalBool(n.left, env, "&&")
+	if err != nil || leftKnown && !left {
+		return false, err
+	}
+	right, rightKnown, err := evalBool(n.right, env, "&&")
+	if err != nil || rightKnown && !right {
+		return false, err
+	}
+	if !leftKnown || !rightKnown {
+		return unset{}, nil
+	}
+	return true, nil
+}
+
+type orNode struct {
+	left, right node
+}
+
+func (n orNode) eval(env map[string]any) (any, error) {
+	left, leftKnown, err := evalBool(n.left, env, "||")
+	if err != nil || leftKnown && left {
+		return left, err
+	}
+	right, rightKnown, err := evalBool(n.right, env, "||")
+	if err != nil || rightKnown && right {
+		return right, err
+	}
+	if !leftKnown || !rightKnown {
+		return unset{}, nil
+	}
+	return false, nil
+}
+
+// evalBool evaluates a boolean operand, which is not known if it is unset.
+func evalBool(n node, env map[string]any, op string) (value, known bool, err error) {
+	v, err := n.eval(env)
+	if err != nil || v == (unset{}) {
+		return false, false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, false, fmt.Errorf("operand of %s is %s, not a boolean", op, typeName(v))
+	}
+	return b, true, nil
+}
+
+type compareNode struct {
+	op          string
+	left, right node
+}
+
+func (n compareNode) eval(env map[string]any) (any, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if left == (unset{}) || right == (unset{}) {
+		return unset{}, nil
+	}
+
+	switch n.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "in":
+		list, ok := right.([]any)
+		if !ok {
+			if right == nil {
+				return false, nil
+			}
+			return nil, fmt.Errorf("right operand of in is %s, not a list", typeName(right))
+		}
+		for _, item := range list {
+			if equal(left, item) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	l, lok := number(left)
+	r, rok := number(right)
+	if !lok || !rok {
+		return nil, fmt.Errorf("cannot compare %s %s %s", typeName(left), n.op, typeName(right))
+	}
+	switch n.op {
+	case "<":
+		return l < r, nil
+	case "<=":
+		return l <= r, nil
+	case ">":
+		return l > r, nil
+	default:
+		return l >= r, nil
+	}
+}
+
+// equal compares two values, treating integer strings as numbers since
+// protojson encodes 64-bit integer fields as strings. An unset value is not
+// equal to anything.
+func equal(a, b any) bool {
+	if x, ok := number(a); ok {
+		if y, ok := number(b); ok {
+			return x == y
+		}
+	}
+	switch x := a.(type) {
+	case nil:
+		return b == nil
+	case string:
+		y, ok := b.(string)
+		return ok && x == y
+	case bool:
+		y, ok := b.(bool)
+		return ok && x == y
+	default:
+		return false
+	}
+}
+
+func number(v any) (float64, bool) {
+	switch x := v.(type) {
+	case float64:
+		return x, true
+	case int:
+		return float64(x), true
+	case int32:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	case string:
+		n, err := strconv.ParseInt(x, 10, 64)
+		return float64(n), err == nil
+	default:
+		return 0, false
+	}
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case unset:
+		return "unset"
+	case nil:
+		return "null"
+	case bool:
+		return "a boolean"
+	case float64, int, int32, int64:
+		return "a number"
+	case string:
+		return "a string"
+	case []any:
+		return "a list"
+	case map[string]any:
+		return "an object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
@@ -0,0 +1,237 @@
+package policy
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		source string
+		want   []token
+	}{
+		{
+			source: `user.id == 12`,
+			want: []token{
+				{kind: tokenIdent, text: "user", pos: 0},
+				{kind: tokenOp, text: ".", pos: 4},
+				{kind: tokenIdent, text: "id", pos: 5},
+				{kind: tokenOp, text: "==", pos: 8},
+				{kind: tokenNumber, text: "12", pos: 11},
+				{kind: tokenEOF, pos: 13},
+			},
+		},
+		{
+			source: `'a\'b' != "c"`,
+			want: []token{
+				{kind: tokenString, text: "a'b", pos: 0},
+				{kind: tokenOp, text: "!=", pos: 7},
+				{kind: tokenString, text: "c", pos: 10},
+				{kind: tokenEOF, pos: 13},
+			},
+		},
+		{
+			source: `!(x>=-1.5)`,
+			want: []token{
+				{kind: tokenOp, text: "!", pos: 0},
+				{kind: tokenOp, text: "(", pos: 1},
+				{kind: tokenIdent, text: "x", pos: 2},
+				{kind: tokenOp, text: ">=", pos: 3},
+				{kind: tokenNumber, text: "-1.5", pos: 5},
+				{kind: tokenOp, text: ")", pos: 9},
+				{kind: tokenEOF, pos: 10},
+			},
+		},
+		{
+			source: "",
+			want:   []token{{kind: tokenEOF, pos: 0}},
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := tokenize(tt.source)
+		if err != nil {
+			t.Errorf("tokenize(%q) failed: %v", tt.source, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("tokenize(%q) = %v, want %v", tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestTokenizeErrors(t *testing.T) {
+	for _, source := range []string{
+		`"unterminated`,
+		`a = b`,
+		`a & b`,
+		`user@id`,
+	} {
+		if _, err := tokenize(source); err == nil {
+			t.Errorf("tokenize(%q) succeeded, want an error", source)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, source := range []string{
+		``,
+		`a ==`,
+		`(a == b`,
+		`a == b)`,
+		`a b`,
+		`user.`,
+		`user.1`,
+		`in [1]`,
+		`[1, 2`,
+		`[1 2]`,
+		`a && `,
+		`!`,
+	} {
+		if _, err := Compile(source); err == nil {
+			t.Errorf("Compile(%q) succeeded, want an error", source)
+		}
+	}
+}
+
+func TestEval(t *testing.T) {
+	env := map[string]any{
+		"site":    "perintis",
+		"origin":  "https://dashboard.perintis.app",
+		"api_key": nil,
+		"user": map[string]any{
+			"id":            float64(7),
+			"authenticated": true,
+			"roles":         []any{"staff"},
+		},
+		"request": map[string]any{
+			"user_id":  float64(7),
+			"owner_id": "7",
+			"big_id":   "9007199254740993",
+			"count":    float64(3),
+			"base":     nil,
+		},
+	}
+
+	tests := []struct {
+		source string
+		want   bool
+	}{
+		{`request.user_id == user.id`, true},
+		{`request.owner_id == user.id`, true},
+		{`request.owner_id != user.id`, false},
+		{`request.big_id == "9007199254740993"`, true},
+		{`site == "perintis" && 'staff' in user.roles`, true},
+		{`site != "perintis" || "admin" in user.roles`, false},
+		{`"admin" in user.roles || request.count >= 3`, true},
+		{`request.count < 3`, false},
+		{`request.count > 2 && request.count <= 3`, true},
+		{`!(site == "rizzume")`, true},
+		{`site in ["rizzume", "perintis"]`, true},
+		{`site in []`, false},
+		{`api_key == null`, true},
+		{`request.base == null`, true},
+		{`user.authenticated`, true},
+		{`!user.authenticated || true`, true},
+		{`false && request.missing.deep`, false},
+		{`true || request.missing.deep`, true},
+
+		// Comparisons with an unset path do not hold whatever the operator.
+		{`request.missing == request.absent`, false},
+		{`request.missing != request.absent`, false},
+		{`request.missing == null`, false},
+		{`request.missing != null`, false},
+		{`request.missing != 1`, false},
+		{`request.base.user_id == null`, false},
+		{`user.name.first == user.name.first`, false},
+		{`request.missing < 1`, false},
+		{`request.missing in [1, 2]`, false},
+		{`1 in request.missing`, false},
+		{`1 in [request.missing]`, false},
+
+		// Unset is unknown to the boolean operators and an unset expression
+		// does not hold.
+		{`request.missing`, false},
+		{`!request.missing`, false},
+		{`!(request.missing == 1)`, false},
+		{`!(request.missing != user.id)`, false},
+		{`!!(request.missing == 1)`, false},
+		{`request.missing == 1 || true`, true},
+		{`true || request.missing == 1`, true},
+		{`request.missing == 1 || false`, false},
+		{`!(request.missing == 1 || false)`, false},
+		{`request.missing == 1 && false`, false},
+		{`!(request.missing == 1 && false)`, true},
+		{`request.missing == 1 && true`, false},
+		{`!(request.missing == 1 && true)`, false},
+	}
+
+	for _, tt := range tests {
+		expr, err := Compile(tt.source)
+		if err != nil {
+			t.Errorf("Compile(%q) failed: %v", tt.source, err)
+			continue
+		}
+		got, err := expr.Eval(env)
+		if err != nil {
+			t.Errorf("Eval(%q) failed: %v", tt.source, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Eval(%q) = %v, want %v", tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	env := map[string]any{
+		"site": "perintis",
+		"user": map[string]any{"roles": []any{"staff"}},
+	}
+
+	for _, source := range []string{
+		`site`,
+		`site && true`,
+		`!site`,
+		`site < 1`,
+		`"staff" in site`,
+	} {
+		expr, err := Compile(source)
+		if err != nil {
+			t.Errorf("Compile(%q) failed: %v", source, err)
+			continue
+		}
+		if _, err := expr.Eval(env); err == nil {
+			t.Errorf("Eval(%q) succeeded, want an error", source)
+		}
+	}
+}
+
+// TestEvalApiKeyCaller checks that an owner policy does not hold for an API
+// key caller, which has no user id, against a request without a user id.
+func TestEvalApiKeyCaller(t *testing.T) {
+	env := map[string]any{
+		"api_key": "partner",
+		"user": map[string]any{
+			"authenticated": false,
+			"name":          "partner",
+			"roles":         []any{},
+		},
+		"request": map[string]any{},
+	}
+
+	for _, source := range []string{
+		`request.user_id == user.id`,
+		`user.id == request.user_id`,
+		`!(request.user_id != user.id)`,
+		`!(request.user_id != user.id) || "admin" in user.roles`,
+	} {
+		expr, err := Compile(source)
+		if err != nil {
+			t.Fatalf("Compile(%q) failed: %v", source, err)
+		}
+		if allowed, err := expr.Eval(env); err != nil || allowed {
+			t.Errorf("Eval(%q) = %v, %v, want false", source, allowed, err)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/gob"
+	"errors"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/auth0"
+	"github.com/cynx-io/janus-gateway/internal/helper"
+	"golang.org/x/oauth2"
+	"net/http"
+	"time"
+)
+
+const (
+	// loginTtl is how long a login attempt may take to come back to the
+	// callback
+	loginTtl = 10 * time.Minute
+
+	// maxLogins bounds the login attempts kept at once, e.g. one per tab
+	maxLogins = 5
+)
+
+// ErrUnknownLogin is returned for a state that belongs to no login attempt of
+// the session, or one that has expired.
+var ErrUnknownLogin = errors.New("unknown or expired login attempt")
+
+// Login is a login attempt in progress, from /auth0/login until Auth0 redirects
+// back to the callback with its state.
+type Login struct {
+	Verifier    string
+	Nonce       string
+	RedirectURL string
+	// Silent attempts use prompt=none and only report whether Auth0 could
+	// log the user in without interaction
+	Silent    bool
+	StartedAt time.Time
+}
+
+func init() {
+	gob.Register(map[string]Login{})
+}
+
+// StartLogin begins login, a login attempt, with a new PKCE verifier and nonce
+// and returns it with the state that identifies it. Attempts are kept by
+// state, so logins started in several tabs can all complete.
+func StartLogin(w http.ResponseWriter, r *http.Request, login Login) (string, *Login, error) {
+	siteKey, err := helper.GetSiteKey(r)
+	if err != nil {
+		return "", nil, err
+	}
+	session, err := auth0.Store(siteKey).Get(r, "auth-session")
+	if err != nil {
+		return "", nil, err
+	}
+
+	state, err := randomToken()
+	if err != nil {
+		return "", nil, err
+	}
+	nonce, err := randomToken()
+	if err != nil {
+		return "", nil, err
+	}
+	login.Verifier = oauth2.GenerateVerifier()
+	login.Nonce = nonce
+	login.StartedAt = time.Now()
+
+	logins := pendingLogins(session.Values)
+	for len(logins) >= maxLogins {
+		delete(logins, oldestLogin(logins))
+	}
+	logins[state] = login
+	session.Values["logins"] = logins
+
+	if err := session.Save(r, w); err != nil {
+		return "", nil, err
+	}
+	return state, &login, nil
+}
+
+// FinishLogin removes and returns the login attempt of state. Each attempt
+// can only be finished once.
+func FinishLogin(w http.ResponseWriter, r *http.Request, state string) (*Login, error) {
+	siteKey, err := helper.GetSiteKey(r)
+	if err != nil {
+		return nil, err
+	}
+	session, err := auth0.Store(siteKey).Get(r, "auth-session")
+	if err != nil {
+		return nil, err
+	}
+
+	logins := pendingLogins(session.Values)
+	login, ok := logins[state]
+	if !ok || state == "" {
+		return nil, ErrUnknownLogin
+	}
+	delete(logins, state)
+	session.Values["logins"] = logins
+
+	if err := session.Save(r, w); err != nil {
+		return nil, err
+	}
+	return &login, nil
+}
+
+// pendingLogins returns the unexpired login attempts of a session.
+func pendingLogins(values map[interface{}]interface{}) map[string]Login {
+	logins := make(map[string]Login)
+	stored, _ := values["logins"].(map[string]Login)
+	for state, login := range stored {
+		if time.Since(login.StartedAt) < loginTtl {
+			logins[state] = login
+		}
+	}
+	return logins
+}
+
+func oldestLogin(logins map[string]Login) string {
+	var oldest string
+	for state, login := range logins {
+		if oldest == "" || login.StartedAt.Before(logins[oldest].StartedAt) {
+			oldest = state
+		}
+	}
+	return oldest
+}
+
+// randomToken returns 32 random bytes, base64url encoded.
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,216 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"github.com/cynx-io/janus-gateway/internal/bearer"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/auth0"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/sessionstore"
+	"github.com/cynx-io/janus-gateway/internal/helper"
+	"github.com/gorilla/sessions"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNoRegistry is returned when sessions are kept in cookies, which cannot
+// be listed or revoked.
+var ErrNoRegistry = errors.New("sessions are not kept server-side")
+
+// ErrUnauthenticated is returned when the request has no logged in session.
+var ErrUnauthenticated = errors.New("not logged in")
+
+// Info describes an active session of a user.
+type Info struct {
+	ID        string    `json:"id"`
+	Site      string    `json:"site"`
+	Device    string    `json:"device"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	CreatedAt time.Time `json:"created_at"`
+	LastSeen  time.Time `json:"last_seen"`
+	Current   bool      `json:"current"`
+}
+
+// register adds a session that was just logged in to the sessions of its user.
+func register(r *http.Request, store sessions.Store, session *sessions.Session, userID string) error {
+	s, ok := store.(*sessionstore.Store)
+	if !ok || userID == "" {
+		return nil
+	}
+	return sessionstore.Register(r.Context(), userID, s.Key(session))
+}
+
+// current returns the store and session of the request, which must be
+// authenticated and kept server-side.
+func current(r *http.Request) (*sessionstore.Store, *sessions.Session, string, error) {
+	siteKey, err := helper.GetSiteKey(r)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	store, ok := auth0.Store(siteKey).(*sessionstore.Store)
+	if !ok {
+		return nil, nil, "", ErrNoRegistry
+	}
+	session, err := store.Get(r, "auth-session")
+	if err != nil {
+		return nil, nil, "", err
+	}
+	userID, _ := session.Values["user_id"].(string)
+	if session.Values["authenticated"] != true || userID == "" {
+		return nil, nil, "", ErrUnauthenticated
+	}
+	return store, session, userID, nil
+}
+
+// ListSessions returns the active sessions of the logged in user across all
+// sites, most recently created first.
+func ListSessions(r *http.Request) ([]Info, error) {
+	store, session, userID, err := current(r)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := sessionstore.Sessions(r.Context(), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	currentKey := store.Key(session)
+	infos := make([]Info, 0, len(entries))
+	for _, entry := range entries {
+		info := Info{
+			ID:      entry.ID(),
+			Site:    siteOf(entry.Key),
+			Current: entry.Key == currentKey,
+		}
+		info.IP, _ = entry.Values["ip"].(string)
+		info.UserAgent, _ = entry.Values["user_agent"].(string)
+		info.CreatedAt, _ = entry.Values["created_at"].(time.Time)
+		info.LastSeen, _ = entry.Values[sessionstore.LastSeenKey].(time.Time)
+		info.Device = device(info.UserAgent)
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].CreatedAt.After(infos[j].CreatedAt)
+	})
+	return infos, nil
+}
+
+// RevokeSession logs out the session of the logged in user with the given id,
+// as returned by ListSessions, and revokes the refresh tokens exchanged for
+// it. It returns false if there is no such session.
+func RevokeSession(w http.ResponseWriter, r *http.Request, id string) (bool, error) {
+	store, session, userID, err := current(r)
+	if err != nil {
+		return false, err
+	}
+	revoked, err := sessionstore.Revoke(r.Context(), userID, id)
+	if err != nil || revoked == 0 {
+		return false, err
+	}
+	if sessionstore.PublicID(store.Key(session)) == id {
+		clearCookie(w, store, session)
+	}
+	return true, bearer.RevokeRefreshTokens(r.Context(), userID, id)
+}
+
+// RevokeAllSessions logs out every session of the logged in user, including
+// the current one, revokes their refresh tokens and returns how many sessions
+// were revoked.
+func RevokeAllSessions(w http.ResponseWriter, r *http.Request) (int, error) {
+	store, session, userID, err := current(r)
+	if err != nil {
+		return 0, err
+	}
+	revoked, err := sessionstore.Revoke(r.Context(), userID)
+	if err != nil {
+		return revoked, err
+	}
+	clearCookie(w, store, session)
+	return revoked, bearer.RevokeRefreshTokens(r.Context(), userID, "")
+}
+
+// RevokeUserSessions logs out every session of userID, revokes their refresh
+// tokens and returns how many sessions were revoked.
+func RevokeUserSessions(ctx context.Context, userID string) (int, error) {
+	if !sessionstore.Enabled() {
+		return 0, ErrNoRegistry
+	}
+	revoked, err := sessionstore.Revoke(ctx, userID)
+	if err != nil {
+		return revoked, err
+	}
+	return revoked, bearer.RevokeRefreshTokens(ctx, userID, "")
+}
+
+// ID returns the public id of the request's session, as listed by
+// ListSessions, or an empty string if it has none.
+func ID(r *http.Request) string {
+	siteKey, err := helper.GetSiteKey(r)
+	if err != nil {
+		return ""
+	}
+	store, ok := auth0.Store(siteKey).(*sessionstore.Store)
+	if !ok {
+		return ""
+	}
+	session, err := store.Get(r, "auth-session")
+	if err != nil || session.ID == "" {
+		return ""
+	}
+	return sessionstore.PublicID(store.Key(session))
+}
+
+// clearCookie expires the session cookie of a session that was already
+// deleted from the store.
+func clearCookie(w http.ResponseWriter, store *sessionstore.Store, session *sessions.Session) {
+	options := *store.Options
+	options.MaxAge = -1
+	http.SetCookie(w, sessions.NewCookie(session.Name(), "", &options))
+}
+
+// siteOf returns the site of a session key, "session:<site>:<id>".
+func siteOf(key string) string {
+	parts := strings.SplitN(key, ":", 3)
+	if len(parts) != 3 {
+		return ""
+	}
+	return parts[1]
+}
+
+// device gives a short description of a user agent, such as
+// "Chrome on macOS".
+func device(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+
+	browser := "Unknown browser"
+	for _, b := range []struct{ token, name string }{
+		{"edg/", "Edge"},
+		{"opr/", "Opera"},
+		{"firefox/", "Firefox"},
+		{"chrome/", "Chrome"},
+		{"safari/", "Safari"},
+	} {
+		if strings.Contains(ua, b.token) {
+			browser = b.name
+			break
+		}
+	}
+
+	system := "unknown OS"
+	for _, o := range []struct{ token, name string }{
+		{"android", "Android"},
+		{"iphone", "iOS"},
+		{"ipad", "iOS"},
+		{"mac os x", "macOS"},
+		{"windows", "Windows"},
+		{"linux", "Linux"},
+	} {
+		if strings.Contains(ua, o.token) {
+			system = o.name
+			break
+		}
+	}
+	return browser + " on " + system
+}
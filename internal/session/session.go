@@ -3,16 +3,22 @@ package session
 import (
 	"encoding/gob"
 	"github.com/cynx-io/janus-gateway/internal/dependencies/auth0"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/sessionstore"
 	"github.com/cynx-io/janus-gateway/internal/helper"
+	"github.com/gorilla/sessions"
 	"net/http"
 	"time"
 )
 
 type UserSession struct {
 	ExpiresAt     time.Time `json:"expires_at"`
+	CheckedAt     time.Time `json:"checked_at"` // last load of the user from Hermes
 	UserID        string    `json:"user_id"`
 	Email         string    `json:"email"`
 	Name          string    `json:"name"`
+	Roles         []string  `json:"roles"`
+	Subject       string    `json:"subject"`
+	Auth0Roles    []string  `json:"auth0_roles"`
 	AccessToken   string    `json:"access_token"`
 	RefreshToken  string    `json:"refresh_token"`
 	Authenticated bool      `json:"authenticated"`
@@ -27,7 +33,7 @@ func GetSession(r *http.Request) (*UserSession, error) {
 	if err != nil {
 		return nil, err
 	}
-	session, err := auth0.Store[siteKey].Get(r, "auth-session")
+	session, err := auth0.Store(siteKey).Get(r, "auth-session")
 	if err != nil {
 		return nil, err
 	}
@@ -43,6 +49,15 @@ func GetSession(r *http.Request) (*UserSession, error) {
 	if name, ok := session.Values["name"].(string); ok {
 		userSession.Name = name
 	}
+	if roles, ok := session.Values["roles"].([]string); ok {
+		userSession.Roles = roles
+	}
+	if subject, ok := session.Values["subject"].(string); ok {
+		userSession.Subject = subject
+	}
+	if auth0Roles, ok := session.Values["auth0_roles"].([]string); ok {
+		userSession.Auth0Roles = auth0Roles
+	}
 	if auth, ok := session.Values["authenticated"].(bool); ok {
 		userSession.Authenticated = auth
 	}
@@ -55,103 +70,70 @@ func GetSession(r *http.Request) (*UserSession, error) {
 	if expiresAt, ok := session.Values["expires_at"].(time.Time); ok {
 		userSession.ExpiresAt = expiresAt
 	}
+	if checkedAt, ok := session.Values["checked_at"].(time.Time); ok {
+		userSession.CheckedAt = checkedAt
+	}
 
 	return userSession, nil
 }
 
+// SetSession updates the values of the request's session, such as tokens
+// after a refresh. Use LogIn to log a session in.
 func SetSession(w http.ResponseWriter, r *http.Request, userSession *UserSession) error {
+	return save(w, r, userSession, false)
+}
+
+// LogIn stores userSession under a new session id and adds it to the
+// sessions of its user. The id is renewed even if the request was already
+// logged in, so an id planted or leaked before the login is useless after it.
+func LogIn(w http.ResponseWriter, r *http.Request, userSession *UserSession) error {
+	return save(w, r, userSession, true)
+}
+
+func save(w http.ResponseWriter, r *http.Request, userSession *UserSession, login bool) error {
 	siteKey, err := helper.GetSiteKey(r)
 	if err != nil {
 		return err
 	}
-	session, err := auth0.Store[siteKey].Get(r, "auth-session")
+	store := auth0.Store(siteKey)
+	session, err := store.Get(r, "auth-session")
 	if err != nil {
 		return err
 	}
 
+	if login {
+		if renewer, ok := store.(interface {
+			Renew(*http.Request, *sessions.Session) error
+		}); ok {
+			if err := renewer.Renew(r, session); err != nil {
+				return err
+			}
+		}
+		session.Values["ip"] = helper.ClientIP(r)
+		session.Values["user_agent"] = r.UserAgent()
+		session.Values["created_at"] = time.Now()
+		session.Values[sessionstore.LastSeenKey] = time.Now()
+	}
+
 	session.Values["user_id"] = userSession.UserID
 	session.Values["email"] = userSession.Email
 	session.Values["name"] = userSession.Name
+	session.Values["roles"] = userSession.Roles
+	session.Values["subject"] = userSession.Subject
+	session.Values["auth0_roles"] = userSession.Auth0Roles
 	session.Values["authenticated"] = userSession.Authenticated
 	session.Values["access_token"] = userSession.AccessToken
 	session.Values["refresh_token"] = userSession.RefreshToken
 	session.Values["expires_at"] = userSession.ExpiresAt
+	session.Values["checked_at"] = userSession.CheckedAt
 
-	return session.Save(r, w)
-}
-
-func SetState(w http.ResponseWriter, r *http.Request, state string) error {
-	siteKey, err := helper.GetSiteKey(r)
-	if err != nil {
+	if err := session.Save(r, w); err != nil {
 		return err
 	}
-	session, err := auth0.Store[siteKey].Get(r, "auth-session")
-	if err != nil {
-		return err
+	if login {
+		return register(r, store, session, userSession.UserID)
 	}
-
-	session.Values["state"] = state
-	return session.Save(r, w)
-}
-
-func GetState(r *http.Request) (string, error) {
-	siteKey, err := helper.GetSiteKey(r)
-	if err != nil {
-		return "", err
-	}
-	session, err := auth0.Store[siteKey].Get(r, "auth-session")
-	if err != nil {
-		return "", err
-	}
-
-	if state, ok := session.Values["state"].(string); ok {
-		return state, nil
-	}
-	return "", nil
-}
-
-func SetRedirectURL(w http.ResponseWriter, r *http.Request, redirectURL string) error {
-	siteKey, err := helper.GetSiteKey(r)
-	if err != nil {
-		return err
-	}
-	session, err := auth0.Store[siteKey].Get(r, "auth-session")
-	if err != nil {
-		return err
-	}
-
-	session.Values["redirect_url"] = redirectURL
-	return session.Save(r, w)
-}
-
-func GetRedirectURL(r *http.Request) (string, error) {
-	siteKey, err := helper.GetSiteKey(r)
-	if err != nil {
-		return "", err
-	}
-	session, err := auth0.Store[siteKey].Get(r, "auth-session")
-	if err != nil {
-		return "", err
-	}
-
-	if redirectURL, ok := session.Values["redirect_url"].(string); ok {
-		return redirectURL, nil
-	}
-	return "", nil
-}
-
-func ClearRedirectURL(w http.ResponseWriter, r *http.Request) error {
-	siteKey, err := helper.GetSiteKey(r)
-	if err != nil {
-		return err
-	}
-	session, err := auth0.Store[siteKey].Get(r, "auth-session")
-	if err != nil {
-		return err
-	}
-
-	delete(session.Values, "redirect_url")
-	return session.Save(r, w)
+	return nil
 }
 
 func ClearSession(w http.ResponseWriter, r *http.Request) error {
@@ -159,7 +141,7 @@ func ClearSession(w http.ResponseWriter, r *http.Request) error {
 	if err != nil {
 		return err
 	}
-	session, err := auth0.Store[siteKey].Get(r, "auth-session")
+	session, err := auth0.Store(siteKey).Get(r, "auth-session")
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,127 @@
+package session
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"github.com/cynx-io/janus-gateway/internal/constant"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/sessionstore"
+	"github.com/cynx-io/janus-gateway/internal/helper"
+	"net/http"
+	"time"
+)
+
+// TransferTtl is how long a transfer code can be redeemed.
+const TransferTtl = time.Minute
+
+// ErrInvalidTransfer is returned for a transfer code that is unknown, used,
+// expired or meant for another site.
+var ErrInvalidTransfer = errors.New("invalid or expired transfer code")
+
+// Transfer hands the logged in user of one site over to another.
+type Transfer struct {
+	From        constant.SiteKey
+	To          constant.SiteKey
+	UserID      string
+	Email       string
+	Name        string
+	Roles       []string
+	Subject     string
+	Auth0Roles  []string
+	RedirectURL string
+}
+
+func transferKey(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return "sso:" + hex.EncodeToString(sum[:])
+}
+
+// NewTransfer returns a one-time code that logs the user of the request's
+// session in on site to, and then sends them to redirectURL.
+func NewTransfer(r *http.Request, to constant.SiteKey, redirectURL string) (string, error) {
+	from, err := helper.GetSiteKey(r)
+	if err != nil {
+		return "", err
+	}
+	userSession, err := GetSession(r)
+	if err != nil {
+		return "", err
+	}
+	if !userSession.Authenticated || userSession.UserID == "" {
+		return "", ErrUnauthenticated
+	}
+
+	// The Auth0 tokens belong to the client of this site and stay here
+	transfer := Transfer{
+		From:        from,
+		To:          to,
+		UserID:      userSession.UserID,
+		Email:       userSession.Email,
+		Name:        userSession.Name,
+		Roles:       userSession.Roles,
+		Subject:     userSession.Subject,
+		Auth0Roles:  userSession.Auth0Roles,
+		RedirectURL: redirectURL,
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(transfer); err != nil {
+		return "", err
+	}
+
+	code, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	if err := sessionstore.Ephemeral().Save(r.Context(), transferKey(code), buf.Bytes(), TransferTtl); err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// RedeemTransfer uses up code and returns its transfer, which must be for the
+// request's site. It does not log the session in, see Accept.
+func RedeemTransfer(r *http.Request, code string) (*Transfer, error) {
+	siteKey, err := helper.GetSiteKey(r)
+	if err != nil {
+		return nil, err
+	}
+	if code == "" {
+		return nil, ErrInvalidTransfer
+	}
+
+	data, err := sessionstore.Take(r.Context(), sessionstore.Ephemeral(), transferKey(code))
+	if errors.Is(err, sessionstore.ErrNotFound) {
+		return nil, ErrInvalidTransfer
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var transfer Transfer
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&transfer); err != nil {
+		return nil, err
+	}
+	if transfer.To != siteKey {
+		return nil, ErrInvalidTransfer
+	}
+	return &transfer, nil
+}
+
+// Accept logs the session of the request in as the user of transfer. The
+// session has no Auth0 tokens to refresh, so it lasts session.ttl like any
+// server-side session, independently of the original one, and the auth
+// middleware checks its user against Hermes periodically instead.
+func (t *Transfer) Accept(w http.ResponseWriter, r *http.Request) error {
+	return LogIn(w, r, &UserSession{
+		UserID:        t.UserID,
+		Email:         t.Email,
+		Name:          t.Name,
+		Roles:         t.Roles,
+		Subject:       t.Subject,
+		Auth0Roles:    t.Auth0Roles,
+		CheckedAt:     time.Now(),
+		Authenticated: true,
+	})
+}
@@ -2,10 +2,16 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/cynx-io/cynx-core/src/logger"
+	"github.com/cynx-io/janus-gateway/internal/apikey"
+	"github.com/cynx-io/janus-gateway/internal/constant"
 	"github.com/cynx-io/janus-gateway/internal/dependencies/auth0"
 	"github.com/cynx-io/janus-gateway/internal/dependencies/config"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/sessionstore"
+	"github.com/cynx-io/janus-gateway/internal/dependencies/upstream"
 	"github.com/cynx-io/janus-gateway/internal/gateway/handlers/ananke"
 	"github.com/cynx-io/janus-gateway/internal/gateway/handlers/janus"
 	"github.com/cynx-io/janus-gateway/internal/gateway/handlers/mercury"
@@ -13,31 +19,119 @@ import (
 	"github.com/cynx-io/janus-gateway/internal/gateway/handlers/plato"
 	"github.com/cynx-io/janus-gateway/internal/gateway/handlers/plutus"
 	"github.com/cynx-io/janus-gateway/internal/gateway/middleware"
+	"github.com/cynx-io/janus-gateway/internal/gateway/routes"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 	"log"
 	"net/http"
+	"os"
 	"strconv"
 )
 
 func main() {
-	log.Println("Starting Janus API Gateway")
-
 	// Load configuration
 	config.Init()
+
+	if len(os.Args) > 1 {
+		runCommand(os.Args[1:])
+		return
+	}
+
+	if err := config.Check(); err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+
+	log.Println("Starting Janus API Gateway")
+	sessionstore.Init()
 	auth0.Init()
 
-	logLevel, err := logrus.ParseLevel(config.Config.Elastic.Level)
+	initLogger(config.Config())
+
+	root := newRouter()
+
+	// Apply config file changes without a restart
+	config.AddValidator(upstream.Validate)
+	config.OnChange(upstream.Reconnect)
+	config.OnChange(func(prev, next *config.AppConfig) {
+		if prev.Elastic != next.Elastic {
+			initLogger(next)
+		}
+	})
+	config.Watch()
+
+	address := ":" + strconv.Itoa(config.Config().App.Port)
+
+	// Create server with middleware
+	server := &http.Server{
+		Addr:    address,
+		Handler: root,
+	}
+
+	// Start server
+	logger.Info(context.Background(), "HTTP server listening on ", address)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		panic("Failed to start server: " + err.Error())
+	}
+}
+
+func initLogger(cfg *config.AppConfig) {
+	logLevel, err := logrus.ParseLevel(cfg.Elastic.Level)
 	if err != nil {
 		logLevel = logrus.DebugLevel
 	}
 
 	logger.Init(logger.LoggerConfig{
 		Level:            logLevel,
-		ElasticsearchURL: []string{config.Config.Elastic.Url},
+		ElasticsearchURL: []string{cfg.Elastic.Url},
 		ServiceName:      "janus-gateway",
 	})
+}
 
+// runCommand runs a CLI subcommand instead of the server, e.g. "janus routes".
+func runCommand(args []string) {
+	switch args[0] {
+	case "routes":
+		list, err := routes.List(newRouter())
+		if err != nil {
+			log.Fatalf("Failed to list routes: %v", err)
+		}
+		if err := routes.Print(os.Stdout, list); err != nil {
+			log.Fatalf("Failed to print routes: %v", err)
+		}
+	case "config":
+		runConfigCommand(args[1:])
+	case "apikey":
+		runApiKeyCommand(args[1:])
+	default:
+		log.Fatalf("Unknown command %q, available commands: routes, config, apikey", args[0])
+	}
+}
+
+func runConfigCommand(args []string) {
+	if len(args) == 0 {
+		log.Fatalf("Usage: janus config validate|print")
+	}
+
+	switch args[0] {
+	case "validate":
+		if err := config.Check(); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid configuration:\n%v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Configuration is valid")
+	case "print":
+		// Effective config after env overrides and secret references
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(config.Config().Redacted()); err != nil {
+			log.Fatalf("Failed to print config: %v", err)
+		}
+	default:
+		log.Fatalf("Unknown config command %q, available commands: validate, print", args[0])
+	}
+}
+
+func newRouter() *mux.Router {
 	janusHandler := janus.NewGatewayHandler()
 	cryptoHandler := mercury.NewCryptoHandler()
 	resumeHandler := philyra.NewResumeHandler()
@@ -57,49 +151,90 @@ func main() {
 	// Create router
 	root := mux.NewRouter()
 	janusHandler.InjectRoutes(root)
+	batchHandler := janus.NewBatchHandler(root)
+	batchHandler.InjectRoutes(root)
 	plutusWebhookXenditHandler.InjectRoutes(root)
 
-	root.Use(middleware.CORSMiddleware)
+	rootMiddleware := []mux.MiddlewareFunc{
+		middleware.ApiKeyMiddleware,
+		middleware.CORSMiddleware,
+		middleware.SiteAccessMiddleware,
+	}
+	root.Use(rootMiddleware...)
+	routes.SetMiddleware(constant.RouteAccessNone, rootMiddleware...)
 
-	publicRouter := root.PathPrefix("").Subrouter()
-	publicRouter.Use(
+	publicMiddleware := []mux.MiddlewareFunc{
 		middleware.PublicAuthMiddleware,
 		middleware.BaseRequestHandler,
 		middleware.LogRequestHandler,
-	)
-	publicRouter.Use(middleware.LogResponseHandler)
+		middleware.LogResponseHandler,
+		middleware.PolicyMiddleware,
+	}
+	publicRouter := root.PathPrefix("").Name(string(constant.RouteAccessPublic)).Subrouter()
+	publicRouter.Use(publicMiddleware...)
+	routes.SetMiddleware(constant.RouteAccessPublic, publicMiddleware...)
 
-	privateRouter := root.PathPrefix("/").Subrouter()
-	privateRouter.Use(
+	privateMiddleware := []mux.MiddlewareFunc{
 		middleware.PrivateAuthMiddleware,
 		middleware.BaseRequestHandler,
 		middleware.LogRequestHandler,
-	)
-	privateRouter.Use(middleware.LogResponseHandler)
+		middleware.LogResponseHandler,
+		middleware.PolicyMiddleware,
+	}
+	privateRouter := root.PathPrefix("/").Name(string(constant.RouteAccessPrivate)).Subrouter()
+	privateRouter.Use(privateMiddleware...)
+	routes.SetMiddleware(constant.RouteAccessPrivate, privateMiddleware...)
+
+	// Admin routes run through the private chain first
+	adminRouter := privateRouter.PathPrefix("").Name(string(constant.RouteAccessAdmin)).Subrouter()
+	adminRouter.Use(middleware.AdminMiddleware)
+	routes.SetMiddleware(constant.RouteAccessAdmin, append(privateMiddleware, middleware.AdminMiddleware)...)
 
 	// Inject routes
+	janusHandler.InjectAdminRoutes(adminRouter)
+	debugHandler := janus.NewDebugHandler(root)
+	debugHandler.InjectRoutes(adminRouter)
+	openAPIHandler := janus.NewOpenAPIHandler(root)
+	openAPIHandler.InjectRoutes(root, publicRouter)
 	cryptoHandler.InjectRoutes(publicRouter, privateRouter)
 	resumeHandler.InjectRoutes(publicRouter, privateRouter)
 	careerProfileHandler.InjectRoutes(publicRouter, privateRouter)
 	autoFillHandler.InjectRoutes(publicRouter, privateRouter)
 	platoAnswerHandler.InjectRoutes(publicRouter, privateRouter)
 	platoAnswerCategoryHandler.InjectRoutes(publicRouter, privateRouter)
-	platoDailyGameHandler.InjectRoutes(publicRouter, privateRouter)
+	platoDailyGameHandler.InjectRoutes(publicRouter, adminRouter)
 	platoModeHandler.InjectRoutes(publicRouter, privateRouter)
-	platoTopicHandler.InjectRoutes(publicRouter, privateRouter)
+	platoTopicHandler.InjectRoutes(publicRouter, privateRouter, adminRouter)
 	anankePreorderHandler.InjectRoutes(publicRouter, privateRouter)
 
-	address := ":" + strconv.Itoa(config.Config.App.Port)
+	// RESTful paths dispatch to the RPC routes above, so they go last
+	restHandler := janus.NewRestHandler(root)
+	restHandler.InjectRoutes(root)
 
-	// Create server with middleware
-	server := &http.Server{
-		Addr:    address,
-		Handler: root,
+	return root
+}
+
+func runApiKeyCommand(args []string) {
+	if len(args) != 3 || args[0] != "new" {
+		log.Fatalf("Usage: janus apikey new <id> <site>")
 	}
 
-	// Start server
-	logger.Info(context.Background(), "HTTP server listening on ", address)
-	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-		panic("Failed to start server: " + err.Error())
+	key, err := apikey.Generate()
+	if err != nil {
+		log.Fatalf("Failed to generate API key: %v", err)
+	}
+
+	// Only the hash goes into config, the key itself is shown once
+	entry := config.ApiKeyConfig{
+		Id:     args[1],
+		Hash:   apikey.Hash(key),
+		Site:   constant.SiteKey(args[2]),
+		Routes: []string{},
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	fmt.Printf("API key: %s\n\nAdd to api_keys:\n", key)
+	if err := encoder.Encode(entry); err != nil {
+		log.Fatalf("Failed to print API key entry: %v", err)
 	}
 }
@@ -12,7 +12,7 @@ import type { Message } from "@bufbuild/protobuf";
  * Describes the file hermes/object.proto.
  */
 export const file_hermes_object: GenFile = /*@__PURE__*/
-  fileDesc("ChNoZXJtZXMvb2JqZWN0LnByb3RvEgZoZXJtZXMiggIKBFVzZXISCgoCaWQYASABKAUSEAoIYXV0aDBfaWQYAiABKAkSDQoFZW1haWwYAyABKAkSDAoEbmFtZRgEIAEoCRIZChFzdWJzY3JpcHRpb25fdGllchgFIAEoCRIuCgpjcmVhdGVkX2F0GAYgASgLMhouZ29vZ2xlLnByb3RvYnVmLlRpbWVzdGFtcBIuCgp1cGRhdGVkX2F0GAcgASgLMhouZ29vZ2xlLnByb3RvYnVmLlRpbWVzdGFtcBIxCg1sYXN0X2xvZ2luX2F0GAggASgLMhouZ29vZ2xlLnByb3RvYnVmLlRpbWVzdGFtcBIRCglpc19hY3RpdmUYCSABKAhCEloQaGVybWVzL2FwaS9wcm90b2IGcHJvdG8z", [file_google_protobuf_timestamp]);
+  fileDesc("ChNoZXJtZXMvb2JqZWN0LnByb3RvEgZoZXJtZXMikQIKBFVzZXISCgoCaWQYASABKAUSEAoIYXV0aDBfaWQYAiABKAkSDQoFZW1haWwYAyABKAkSDAoEbmFtZRgEIAEoCRIZChFzdWJzY3JpcHRpb25fdGllchgFIAEoCRIuCgpjcmVhdGVkX2F0GAYgASgLMhouZ29vZ2xlLnByb3RvYnVmLlRpbWVzdGFtcBIuCgp1cGRhdGVkX2F0GAcgASgLMhouZ29vZ2xlLnByb3RvYnVmLlRpbWVzdGFtcBIxCg1sYXN0X2xvZ2luX2F0GAggASgLMhouZ29vZ2xlLnByb3RvYnVmLlRpbWVzdGFtcBIRCglpc19hY3RpdmUYCSABKAgSDQoFcm9sZXMYCiADKAlCEloQaGVybWVzL2FwaS9wcm90b2IGcHJvdG8z", [file_google_protobuf_timestamp]);
 
 /**
  * @generated from message hermes.User
@@ -62,6 +62,11 @@ export type User = Message<"hermes.User"> & {
    * @generated from field: bool is_active = 9;
    */
   isActive: boolean;
+
+  /**
+   * @generated from field: repeated string roles = 10;
+   */
+  roles: string[];
 };
 
 /**